@@ -0,0 +1,139 @@
+package navaros
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// requestAPIVersion returns the API version requested by a request. The
+// Api-Version header is used if present. Otherwise the Accept header's media
+// ranges with a version are used - either vendor media types with a version
+// label, such as application/vnd.acme.v2+json, or ones with a version
+// parameter, such as application/json; version=2. Ranges are tried from the
+// highest q weight to the lowest, in the order listed for equal weights, and
+// the first with a version that hasAPIVersion reports is used. Ranges with a
+// weight of 0 are never used. If none of the versions are available, the
+// most preferred is returned. An empty string is returned if the request does
+// not specify a version.
+func requestAPIVersion(req *http.Request, hasAPIVersion func(version string) bool) string {
+	if version := strings.TrimSpace(req.Header.Get("Api-Version")); version != "" {
+		return normalizeAPIVersion(version)
+	}
+
+	var acceptedVersions []acceptedAPIVersion
+	for _, accept := range req.Header.Values("Accept") {
+		for accept != "" {
+			var mediaRange string
+			mediaRange, accept, _ = strings.Cut(accept, ",")
+			version := mediaRangeAPIVersion(mediaRange)
+			if version == "" {
+				continue
+			}
+			if weight := mediaRangeWeight(mediaRange); weight > 0 {
+				acceptedVersions = append(acceptedVersions, acceptedAPIVersion{version: version, weight: weight})
+			}
+		}
+	}
+	if len(acceptedVersions) == 0 {
+		return ""
+	}
+
+	slices.SortStableFunc(acceptedVersions, func(a, b acceptedAPIVersion) int {
+		return cmp.Compare(b.weight, a.weight)
+	})
+	for _, acceptedVersion := range acceptedVersions {
+		if hasAPIVersion(acceptedVersion.version) {
+			return acceptedVersion.version
+		}
+	}
+	return acceptedVersions[0].version
+}
+
+// acceptedAPIVersion is an API version given by a media range from an Accept
+// header, and the range's q weight.
+type acceptedAPIVersion struct {
+	version string
+	weight  float64
+}
+
+// mediaRangeWeight returns the q weight of a media range from an Accept
+// header. Ranges without a weight, or with one that cannot be parsed, have a
+// weight of 1.
+func mediaRangeWeight(mediaRange string) float64 {
+	_, params, _ := strings.Cut(mediaRange, ";")
+	for params != "" {
+		var param string
+		param, params, _ = strings.Cut(params, ";")
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), "q") {
+			continue
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || weight > 1 {
+			return 1
+		}
+		return max(weight, 0)
+	}
+	return 1
+}
+
+// mediaRangeAPIVersion returns the API version given by a media range from an
+// Accept header, or an empty string if it does not have one.
+func mediaRangeAPIVersion(mediaRange string) string {
+	mediaType, params, _ := strings.Cut(mediaRange, ";")
+	for params != "" {
+		var param string
+		param, params, _ = strings.Cut(params, ";")
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "version") {
+			return normalizeAPIVersion(strings.Trim(strings.TrimSpace(value), `"`))
+		}
+	}
+
+	_, subtype, ok := strings.Cut(strings.TrimSpace(mediaType), "/")
+	if !ok || !strings.HasPrefix(subtype, "vnd.") {
+		return ""
+	}
+	subtype, _, _ = strings.Cut(subtype, "+")
+	subtype = subtype[len("vnd."):]
+	for subtype != "" {
+		var label string
+		label, subtype, _ = strings.Cut(subtype, ".")
+		if isAPIVersionLabel(label) {
+			// Version labels like v2.1 span more than one dot separated label.
+			for subtype != "" && isDigit(subtype[0]) {
+				var minor string
+				minor, subtype, _ = strings.Cut(subtype, ".")
+				label += "." + minor
+			}
+			return normalizeAPIVersion(label)
+		}
+	}
+	return ""
+}
+
+// isAPIVersionLabel reports whether a media type label is a version, such as
+// v2.
+func isAPIVersionLabel(label string) bool {
+	if len(label) < 2 || label[0] != 'v' && label[0] != 'V' {
+		return false
+	}
+	for i := 1; i < len(label); i += 1 {
+		if !isDigit(label[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeAPIVersion strips the v prefix from versions like v2, so that they
+// compare equal to 2.
+func normalizeAPIVersion(version string) string {
+	if len(version) > 1 && (version[0] == 'v' || version[0] == 'V') && isDigit(version[1]) {
+		return version[1:]
+	}
+	return version
+}
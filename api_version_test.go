@@ -0,0 +1,152 @@
+package navaros_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RobertWHurst/navaros"
+)
+
+func TestRouterVersionFromHeaders(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/users", func(ctx *navaros.Context) {
+		ctx.Body = "v1 " + ctx.Version()
+	}, navaros.WithVersion("v1"))
+	router.Get("/users", func(ctx *navaros.Context) {
+		ctx.Body = "v2 " + ctx.Version()
+	}, navaros.WithVersion("v2"))
+
+	cases := []struct {
+		message      string
+		header       string
+		value        string
+		expectedBody string
+	}{
+		{"should use the Api-Version header", "Api-Version", "2", "v2 2"},
+		{"should accept a v prefix in the Api-Version header", "Api-Version", "v1", "v1 1"},
+		{"should use a vendor media type", "Accept", "application/vnd.acme.v2+json", "v2 2"},
+		{"should use the first of equally weighted media types", "Accept", "text/html, application/vnd.acme.v1+json, application/vnd.acme.v2+json", "v1 1"},
+		{"should prefer the media type with the highest weight", "Accept", "application/vnd.acme.v1+json;q=0.5, application/vnd.acme.v2+json;q=0.9", "v2 2"},
+		{"should fall back to a later version the router has", "Accept", "application/vnd.acme.v3+json, application/vnd.acme.v2+json;q=0.8", "v2 2"},
+		{"should ignore media types with a weight of 0", "Accept", "application/vnd.acme.v2+json;q=0, application/vnd.acme.v1+json;q=0.1", "v1 1"},
+		{"should use a version parameter", "Accept", "application/json; version=2", "v2 2"},
+		{"should match the first route without a version", "Accept", "application/json", "v1 "},
+	}
+
+	for _, c := range cases {
+		t.Run(c.message, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/users", nil)
+			req.Header.Set(c.header, c.value)
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			if res.Body.String() != c.expectedBody {
+				t.Errorf("expected %q, got %q", c.expectedBody, res.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouterUnknownVersion(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/users", func(ctx *navaros.Context) {}, navaros.WithVersion("v1"))
+	router.Get("/users", func(ctx *navaros.Context) {}, navaros.WithVersion("v2"))
+	router.Get("/health", func(ctx *navaros.Context) {
+		ctx.Body = "ok"
+	})
+
+	req := httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set("Api-Version", "3")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusNotAcceptable {
+		t.Errorf("expected 406, got %d", res.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/health", nil)
+	req.Header.Set("Api-Version", "3")
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Body.String() != "ok" {
+		t.Errorf("expected unversioned routes to match any version, got %d %s", res.Code, res.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/missing", nil)
+	req.Header.Set("Api-Version", "3")
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", res.Code)
+	}
+}
+
+func TestRouterSetDefaultVersion(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/users", func(ctx *navaros.Context) {
+		ctx.Body = "v1 " + ctx.Version()
+	}, navaros.WithVersion("v1"))
+	router.Get("/users", func(ctx *navaros.Context) {
+		ctx.Body = "v2 " + ctx.Version()
+	}, navaros.WithVersion("v2"))
+	router.SetDefaultVersion("v2")
+
+	for _, version := range []string{"", "3"} {
+		req := httptest.NewRequest("GET", "/users", nil)
+		if version != "" {
+			req.Header.Set("Api-Version", version)
+		}
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		if res.Body.String() != "v2 2" {
+			t.Errorf("expected default version for %q, got %d %s", version, res.Code, res.Body.String())
+		}
+	}
+}
+
+func TestRouterVersionedSubRouter(t *testing.T) {
+	v2Router := navaros.NewRouter()
+	v2Router.PublicGet("/users", func(ctx *navaros.Context) {
+		ctx.Body = "v2"
+	})
+
+	router := navaros.NewRouter()
+	router.Use(v2Router, navaros.WithVersion("2"))
+
+	req := httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set("Api-Version", "2")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Body.String() != "v2" {
+		t.Errorf("expected v2, got %s", res.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set("Api-Version", "1")
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusNotAcceptable {
+		t.Errorf("expected 406, got %d", res.Code)
+	}
+
+	descriptors := router.RouteDescriptors()
+	if len(descriptors) != 1 || descriptors[0].Version != "2" {
+		t.Errorf("expected a descriptor for version 2, got %v", descriptors)
+	}
+}
+
+func TestRouterVersionMethodNotAllowed(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/users", func(ctx *navaros.Context) {}, navaros.WithVersion("1"))
+	router.Post("/users", func(ctx *navaros.Context) {}, navaros.WithVersion("2"))
+
+	req := httptest.NewRequest("POST", "/users", nil)
+	req.Header.Set("Api-Version", "1")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", res.Code)
+	}
+	if res.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Errorf("expected Allow of GET, HEAD, OPTIONS, got %s", res.Header().Get("Allow"))
+	}
+}
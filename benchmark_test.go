@@ -3,6 +3,7 @@ package navaros_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 
 	"github.com/RobertWHurst/navaros"
@@ -135,6 +136,23 @@ func BenchmarkParamsExtraction(b *testing.B) {
 	}
 }
 
+func BenchmarkParamLookup(b *testing.B) {
+	router := navaros.NewRouter()
+	router.Get("/users/:userId/posts/:postId", func(ctx *navaros.Context) {
+		_ = ctx.Param("userId")
+		_ = ctx.Param("postId")
+		ctx.Status = http.StatusOK
+	})
+
+	req := httptest.NewRequest("GET", "/users/123/posts/456", nil)
+	w := httptest.NewRecorder()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(w, req)
+	}
+}
+
 func BenchmarkMiddlewareChain(b *testing.B) {
 	router := navaros.NewRouter()
 	router.Use(func(ctx *navaros.Context) {
@@ -162,3 +180,31 @@ func BenchmarkMiddlewareChain(b *testing.B) {
 		router.ServeHTTP(w, req)
 	}
 }
+
+func BenchmarkRequestRoutingManyRoutes(b *testing.B) {
+	router := navaros.NewRouter()
+	router.Use(func(ctx *navaros.Context) {
+		ctx.Next()
+	})
+	for i := 0; i < 100; i++ {
+		resource := "/resource" + strconv.Itoa(i)
+		router.Get(resource, func(ctx *navaros.Context) {})
+		router.Post(resource, func(ctx *navaros.Context) {})
+		router.Get(resource+"/:id", func(ctx *navaros.Context) {})
+		router.Put(resource+"/:id", func(ctx *navaros.Context) {})
+		router.Delete(resource+"/:id", func(ctx *navaros.Context) {})
+		router.Get(resource+"/:id/items/:itemId(\\d+)", func(ctx *navaros.Context) {})
+	}
+	router.Get("/users/:id", func(ctx *navaros.Context) {
+		ctx.Status = http.StatusOK
+		ctx.Body = ctx.Params().Get("id")
+	})
+
+	req := httptest.NewRequest("GET", "/users/123", nil)
+	w := httptest.NewRecorder()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(w, req)
+	}
+}
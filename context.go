@@ -4,11 +4,14 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"maps"
 	"net/http"
 	"net/url"
 	"slices"
+	"strings"
 	"sync"
 	"time"
 )
@@ -31,19 +34,26 @@ type Context struct {
 	request       *http.Request
 
 	// Mutable - protected by mu
-	method HTTPMethod
-	path   string
-	params RequestParams
-
-	Status            int
-	Headers           http.Header
-	Cookies           []*http.Cookie
-	Body              any
-	responseWriter    http.ResponseWriter
-	bodyWriter        http.ResponseWriter
-	hasWrittenHeaders bool
-	hasWrittenBody    bool
-	inhibitResponse   bool
+	method         HTTPMethod
+	path           string
+	host           string
+	version        string
+	params         contextParams
+	wildcards      []string
+	headMatchesGet bool
+	matchFlags     matchFlags
+	foldParamCase  bool
+
+	Status             int
+	Headers            http.Header
+	Cookies            []*http.Cookie
+	Body               any
+	responseWriter     http.ResponseWriter
+	bodyWriter         http.ResponseWriter
+	hasWrittenHeaders  bool
+	hasWrittenBody     bool
+	hasWrittenResponse bool
+	inhibitResponse    bool
 
 	MaxRequestBodySize int64
 
@@ -52,10 +62,22 @@ type Context struct {
 	FinalError      error
 	FinalErrorStack string
 
+	// errorHandler is the OnError function of the router handling the
+	// context, and hasHandledError is set once it has rendered Error.
+	errorHandler    func(ctx *Context, err error)
+	hasHandledError bool
+
+	// logger is the logger of the router handling the context, and
+	// hasLoggedError is set once Error has been reported to it.
+	logger         *slog.Logger
+	hasLoggedError bool
+
 	requestBodyUnmarshaller func(ctx *Context, into any) error
 	responseBodyMarshaller  func(ctx *Context, from any) (io.Reader, error)
 
 	wrapHandlers                     []HandlerFunc
+	handlerNodes                     []*HandlerNode
+	handlerNodeIndex                 int
 	currentHandlerNode               *HandlerNode
 	currentHandlerNodeMatches        bool
 	matchedPattern                   *Pattern
@@ -65,10 +87,29 @@ type Context struct {
 	currentWrapHandler               HandlerFunc
 	nextBeyondEnd                    bool
 
-	associatedValues map[string]any
+	// routePattern is the pattern of the last route matched by the context or
+	// its sub contexts, and routePrefix the path of the router it belongs to
+	// was mounted at, as mountPrefix is for the router handling the context.
+	// They are used to describe the request in logs.
+	routePattern *Pattern
+	routePrefix  string
+	mountPrefix  string
+
+	// routerSnapshots holds the snapshot of each router the request has been
+	// routed through. It is only used on the root context.
+	routerSnapshots []routerSnapshotRef
+
+	associatedValues map[any]any
 
 	deadline    *time.Time
 	doneChannel chan struct{}
+
+	// isDone and doneErr record that doneChannel has been closed, and why if
+	// the request's context or the deadline caused it. stopWatchDone stops
+	// the watch which closes it when they are done.
+	isDone        bool
+	doneErr       error
+	stopWatchDone func()
 }
 
 var _ context.Context = &Context{}
@@ -91,9 +132,31 @@ func NewContext(res http.ResponseWriter, req *http.Request, handlers ...any) *Co
 // used by libraries which wish to extend or encapsulate the functionality of
 // Navaros. For example, implementing a custom router.
 func NewContextWithNode(res http.ResponseWriter, req *http.Request, firstHandlerNode *HandlerNode) *Context {
+	ctx := newContext(res, req)
+	ctx.handlerNodes = appendHandlerChain(ctx.handlerNodes, firstHandlerNode)
+	ctx.beginHandlerNodes()
+	return ctx
+}
+
+// NewSubContextWithNode creates a new Context from an existing Context. This
+// is useful when you want to create a new Context from an existing one, but
+// with a different handler chain. Note that when the end of the sub context's
+// handler chain is reached, the parent context's handler chain will continue.
+func NewSubContextWithNode(ctx *Context, firstHandlerNode *HandlerNode) *Context {
+	subContext := newSubContext(ctx)
+	subContext.handlerNodes = appendHandlerChain(subContext.handlerNodes, firstHandlerNode)
+	subContext.beginHandlerNodes()
+	return subContext
+}
+
+// newContext creates a context for a request without any handler nodes. The
+// caller is responsible for setting the handler nodes and calling
+// beginHandlerNodes.
+func newContext(res http.ResponseWriter, req *http.Request) *Context {
 	ctx := contextFromPool()
 
 	ctx.request = req
+	ctx.responseWriter = res
 
 	method, err := HTTPMethodFromString(req.Method)
 	if err != nil {
@@ -101,20 +164,16 @@ func NewContextWithNode(res http.ResponseWriter, req *http.Request, firstHandler
 		return ctx
 	}
 	ctx.method = method
-	ctx.path = req.URL.Path
-
-	ctx.responseWriter = res
-
-	ctx.currentHandlerNode = firstHandlerNode
+	ctx.path = req.URL.EscapedPath()
+	ctx.host = hostWithoutPort(req.Host)
 
 	return ctx
 }
 
-// NewSubContextWithNode creates a new Context from an existing Context. This
-// is useful when you want to create a new Context from an existing one, but
-// with a different handler chain. Note that when the end of the sub context's
-// handler chain is reached, the parent context's handler chain will continue.
-func NewSubContextWithNode(ctx *Context, firstHandlerNode *HandlerNode) *Context {
+// newSubContext creates a sub context of ctx without any handler nodes. The
+// caller is responsible for setting the handler nodes and calling
+// beginHandlerNodes.
+func newSubContext(ctx *Context) *Context {
 	subContext := contextFromPool()
 
 	subContext.parentContext = ctx
@@ -123,9 +182,14 @@ func NewSubContextWithNode(ctx *Context, firstHandlerNode *HandlerNode) *Context
 
 	subContext.method = ctx.method
 	subContext.path = ctx.path
-	for k, v := range ctx.params {
-		subContext.params[k] = v
-	}
+	subContext.host = ctx.host
+	subContext.version = ctx.version
+	subContext.headMatchesGet = ctx.headMatchesGet
+	subContext.matchFlags = ctx.matchFlags
+	subContext.foldParamCase = ctx.foldParamCase
+	subContext.routePattern = ctx.routePattern
+	subContext.routePrefix = ctx.routePrefix
+	subContext.mountPrefix = ctx.mountPrefix
 
 	subContext.Status = ctx.Status
 	subContext.Headers = maps.Clone(ctx.Headers)
@@ -136,6 +200,7 @@ func NewSubContextWithNode(ctx *Context, firstHandlerNode *HandlerNode) *Context
 	subContext.wrapHandlers = ctx.wrapHandlers
 	subContext.hasWrittenHeaders = ctx.hasWrittenHeaders
 	subContext.hasWrittenBody = ctx.hasWrittenBody
+	subContext.hasWrittenResponse = ctx.hasWrittenResponse
 
 	subContext.MaxRequestBodySize = ctx.MaxRequestBodySize
 
@@ -143,6 +208,10 @@ func NewSubContextWithNode(ctx *Context, firstHandlerNode *HandlerNode) *Context
 	subContext.ErrorStack = ctx.ErrorStack
 	subContext.FinalError = ctx.FinalError
 	subContext.FinalErrorStack = ctx.FinalErrorStack
+	subContext.errorHandler = ctx.errorHandler
+	subContext.hasHandledError = ctx.hasHandledError
+	subContext.logger = ctx.logger
+	subContext.hasLoggedError = ctx.hasLoggedError
 
 	subContext.requestBodyUnmarshaller = ctx.requestBodyUnmarshaller
 	subContext.responseBodyMarshaller = ctx.responseBodyMarshaller
@@ -152,20 +221,94 @@ func NewSubContextWithNode(ctx *Context, firstHandlerNode *HandlerNode) *Context
 	}
 
 	subContext.deadline = ctx.deadline
-	subContext.doneChannel = ctx.doneChannel
-
-	subContext.currentHandlerNode = firstHandlerNode
 
 	return subContext
 }
 
+// appendHandlerChain appends each handler node in the chain starting at
+// firstHandlerNode to handlerNodes.
+func appendHandlerChain(handlerNodes []*HandlerNode, firstHandlerNode *HandlerNode) []*HandlerNode {
+	for handlerNode := firstHandlerNode; handlerNode != nil; handlerNode = handlerNode.Next {
+		handlerNodes = append(handlerNodes, handlerNode)
+	}
+	return handlerNodes
+}
+
+// beginHandlerNodes positions the context at the first of its handler nodes.
+func (c *Context) beginHandlerNodes() {
+	c.handlerNodeIndex = 0
+	c.currentHandlerNode = nil
+	if len(c.handlerNodes) != 0 {
+		c.currentHandlerNode = c.handlerNodes[0]
+	}
+}
+
+// runHandlerNode runs the handlers of a single handler node over the context
+// after its handler chain has finished. Routers use this to respond to
+// requests that none of their handlers responded to.
+func (c *Context) runHandlerNode(handlerNode *HandlerNode) {
+	clear(c.handlerNodes)
+	c.handlerNodes = append(c.handlerNodes[:0], handlerNode)
+	c.beginHandlerNodes()
+	c.currentHandlerNodeMatches = false
+	c.currentHandlerOrTransformerIndex = 0
+	c.currentHandlerOrTransformer = nil
+	c.currentWrapHandlerIndex = 0
+	c.currentWrapHandler = nil
+	c.nextBeyondEnd = false
+	c.Next()
+}
+
+// handleError passes the context's error to the error handler of the router
+// handling it, set with OnError, so it can render the error response. Nothing
+// is done if there's no error, it has already been handled, or the response
+// has been started. If the error handler panics, the panic replaces the
+// error, and the response is the default 500.
+func (c *Context) handleError() {
+	if c.Error == nil || c.errorHandler == nil || c.hasHandledError || c.hasWrittenHeaders || c.hasWrittenResponse {
+		return
+	}
+	err := c.Error
+	c.Status = http.StatusInternalServerError
+	c.Body = nil
+	c.hasHandledError = true
+	execWithCtxRecovery(c, func() {
+		c.errorHandler(c, err)
+	})
+	if c.Error != err {
+		c.hasHandledError = false
+	}
+}
+
+// isUnhandled reports whether no handler has responded to the request, or
+// errored. Such requests would otherwise be finalized as a 404.
+func (c *Context) isUnhandled() bool {
+	return c.Error == nil &&
+		c.Status == 0 &&
+		c.Body == nil &&
+		!c.hasWrittenHeaders &&
+		!c.hasWrittenBody &&
+		!c.hasWrittenResponse &&
+		!c.inhibitResponse
+}
+
+// advanceHandlerNode moves the context on to its next handler node, setting
+// the current handler node to nil once there are none left.
+func (c *Context) advanceHandlerNode() {
+	c.handlerNodeIndex += 1
+	if c.handlerNodeIndex < len(c.handlerNodes) {
+		c.currentHandlerNode = c.handlerNodes[c.handlerNodeIndex]
+	} else {
+		c.currentHandlerNode = nil
+	}
+}
+
 var contextPool = sync.Pool{
 	New: func() any {
 		return &Context{
-			params:           RequestParams{},
 			Headers:          http.Header{},
 			Cookies:          []*http.Cookie{},
-			associatedValues: map[string]any{},
+			associatedValues: map[any]any{},
 		}
 	},
 }
@@ -181,9 +324,13 @@ func (c *Context) free() {
 
 	c.method = All
 	c.path = ""
-	for k := range c.params {
-		delete(c.params, k)
-	}
+	c.host = ""
+	c.version = ""
+	c.headMatchesGet = false
+	c.matchFlags = 0
+	c.params.reset()
+	c.foldParamCase = false
+	c.wildcards = c.wildcards[:0]
 
 	c.Status = 0
 	for k := range c.Headers {
@@ -195,6 +342,7 @@ func (c *Context) free() {
 	c.bodyWriter = nil
 	c.hasWrittenHeaders = false
 	c.hasWrittenBody = false
+	c.hasWrittenResponse = false
 	c.inhibitResponse = false
 
 	c.MaxRequestBodySize = 0
@@ -203,10 +351,17 @@ func (c *Context) free() {
 	c.ErrorStack = ""
 	c.FinalError = nil
 	c.FinalErrorStack = ""
+	c.errorHandler = nil
+	c.hasHandledError = false
+	c.logger = nil
+	c.hasLoggedError = false
 
 	c.requestBodyUnmarshaller = nil
 	c.responseBodyMarshaller = nil
 
+	clear(c.handlerNodes)
+	c.handlerNodes = c.handlerNodes[:0]
+	c.handlerNodeIndex = 0
 	c.currentHandlerNode = nil
 	c.currentHandlerNodeMatches = false
 	c.matchedPattern = nil
@@ -216,6 +371,11 @@ func (c *Context) free() {
 	c.currentWrapHandlerIndex = 0
 	c.currentWrapHandler = nil
 	c.nextBeyondEnd = false
+	c.routePattern = nil
+	c.routePrefix = ""
+	c.mountPrefix = ""
+	clear(c.routerSnapshots)
+	c.routerSnapshots = c.routerSnapshots[:0]
 
 	for k := range c.associatedValues {
 		delete(c.associatedValues, k)
@@ -224,12 +384,30 @@ func (c *Context) free() {
 	c.deadline = nil
 
 	c.mu.Lock()
+	c.stopWatchingDone()
 	c.doneChannel = nil
+	c.isDone = false
+	c.doneErr = nil
 	c.mu.Unlock()
 
 	contextPool.Put(c)
 }
 
+// hostWithoutPort returns the host of a request's Host header, without any
+// port or the brackets around an IPv6 address.
+func hostWithoutPort(host string) string {
+	if strings.HasPrefix(host, "[") {
+		if i := strings.IndexByte(host, ']'); i != -1 {
+			return host[1:i]
+		}
+		return host
+	}
+	if i := strings.LastIndexByte(host, ':'); i != -1 {
+		return host[:i]
+	}
+	return host
+}
+
 // tryUpdateParent updates the parent context with the current context's
 // state. This is called by Next() when the current context is a sub context.
 func (c *Context) tryUpdateParent() {
@@ -243,6 +421,7 @@ func (c *Context) tryUpdateParent() {
 	c.parentContext.Body = c.Body
 	c.parentContext.hasWrittenHeaders = c.hasWrittenHeaders
 	c.parentContext.hasWrittenBody = c.hasWrittenBody
+	c.parentContext.hasWrittenResponse = c.hasWrittenResponse
 
 	c.parentContext.MaxRequestBodySize = c.MaxRequestBodySize
 
@@ -250,6 +429,10 @@ func (c *Context) tryUpdateParent() {
 	c.parentContext.ErrorStack = c.ErrorStack
 	c.parentContext.FinalError = c.FinalError
 	c.parentContext.FinalErrorStack = c.FinalErrorStack
+	c.parentContext.hasHandledError = c.hasHandledError
+	c.parentContext.hasLoggedError = c.hasLoggedError
+	c.parentContext.routePattern = c.routePattern
+	c.parentContext.routePrefix = c.routePrefix
 
 	c.parentContext.requestBodyUnmarshaller = c.requestBodyUnmarshaller
 	c.parentContext.responseBodyMarshaller = c.responseBodyMarshaller
@@ -258,45 +441,59 @@ func (c *Context) tryUpdateParent() {
 		c.parentContext.associatedValues[k] = v
 	}
 	c.parentContext.deadline = c.deadline
-
-	c.parentContext.mu.Lock()
-	c.parentContext.doneChannel = c.doneChannel
-	c.parentContext.mu.Unlock()
 }
 
 // Set attaches a value to the context. It can later be retrieved with Get.
 // This method is thread-safe.
 func (c *Context) Set(key string, value any) {
-	c.mu.Lock()
-	if c.responseWriter == nil {
-		c.mu.Unlock()
-		panic("context cannot be used after handler returns - handlers must block until all operations complete")
-	}
-	c.associatedValues[key] = value
-	c.mu.Unlock()
+	c.setValue(key, value)
 }
 
 // Get retrieves a value attached to the context with Set.
 // This method is thread-safe.
 func (c *Context) Get(key string) (any, bool) {
-	c.mu.RLock()
-	v, ok := c.associatedValues[key]
-	c.mu.RUnlock()
-	return v, ok
+	return c.getValue(key)
 }
 
 // MustGet retrieves a value attached to the context with Set. It panics
 // if the value does not exist. This method is thread-safe.
 func (c *Context) MustGet(key string) any {
-	c.mu.RLock()
-	v := c.associatedValues[key]
-	c.mu.RUnlock()
+	v, ok := c.getValue(key)
+	if !ok {
+		panic(fmt.Sprintf("no value set on the context for key %q", key))
+	}
 	return v
 }
 
 // Delete removes a value attached to the context with Set.
 // This method is thread-safe.
 func (c *Context) Delete(key string) {
+	c.deleteValue(key)
+}
+
+// setValue attaches a value to the context under a string key, or a Key.
+func (c *Context) setValue(key any, value any) {
+	c.mu.Lock()
+	if c.responseWriter == nil {
+		c.mu.Unlock()
+		panic("context cannot be used after handler returns - handlers must block until all operations complete")
+	}
+	c.associatedValues[key] = value
+	c.mu.Unlock()
+}
+
+// getValue retrieves a value attached to the context under a string key, or
+// a Key.
+func (c *Context) getValue(key any) (any, bool) {
+	c.mu.RLock()
+	v, ok := c.associatedValues[key]
+	c.mu.RUnlock()
+	return v, ok
+}
+
+// deleteValue removes a value attached to the context under a string key, or
+// a Key.
+func (c *Context) deleteValue(key any) {
 	c.mu.Lock()
 	delete(c.associatedValues, key)
 	c.mu.Unlock()
@@ -307,9 +504,39 @@ func (c *Context) Method() HTTPMethod {
 	return c.method
 }
 
-// Path returns the path of the request.
+// Path returns the decoded path of the request. If the router cleans paths
+// with LenientPath, this is the cleaned path the request was matched with,
+// rather than the path it was sent with. Within a router mounted with a path
+// this is still the full path of the request, not the path relative to the
+// mount point.
 func (c *Context) Path() string {
-	return c.path
+	return unescapePathValue(c.rootContext().path)
+}
+
+// rootContext returns the context a router created for the request, which
+// the context is a sub context of, or the context itself if it is not a
+// sub context.
+func (c *Context) rootContext() *Context {
+	rootCtx := c
+	for rootCtx.parentContext != nil {
+		rootCtx = rootCtx.parentContext
+	}
+	return rootCtx
+}
+
+// Version returns the API version requested by the request, with any leading
+// v removed, or the router's default version if it did not request one the
+// router knows. An empty string is returned if the router has no versioned
+// routes, or neither the request nor the router specify a version.
+func (c *Context) Version() string {
+	return c.version
+}
+
+// acceptsAPIVersion reports whether a route bound to the given API version
+// may handle the request. Routes without a version accept every request, and
+// requests without a version are accepted by every route.
+func (c *Context) acceptsAPIVersion(version string) bool {
+	return version == "" || c.version == "" || version == c.version
 }
 
 // Pattern returns the route pattern string that matched the request (e.g.
@@ -329,15 +556,51 @@ func (c *Context) URL() *url.URL {
 // Params returns the parameters of the request. These are defined by the
 // route pattern used to bind each handler, and may be different for each
 // time next is called. This method is thread-safe and returns a copy of
-// the params map.
+// the params. Use Param to read a single parameter without copying them.
 func (c *Context) Params() RequestParams {
+	params := RequestParams{}
+	for current := c; current != nil; current = current.parentContext {
+		current.mu.RLock()
+		current.params.each(func(key, value string) {
+			if _, ok := params[key]; !ok {
+				params[key] = value
+			}
+		})
+		current.mu.RUnlock()
+	}
+	return params
+}
+
+// Wildcards returns the values matched by the unnamed wildcard segments of
+// the route pattern used to bind the current handler, in order. Like Params,
+// they may be different each time next is called. Wildcards with a repeating
+// modifier capture every segment they match, so for a handler bound to
+// "/static/**", a request for "/static/css/site.css" has the wildcard
+// "css/site.css". Named wildcards, such as "*path", are found in Params
+// instead. The values are percent-decoded, so "/static/a%2Fb/c" also has the
+// wildcard "a/b/c"; use RawWildcards to tell them apart. This method is
+// thread-safe and returns a copy of the wildcards.
+func (c *Context) Wildcards() []string {
 	c.mu.RLock()
-	params := make(RequestParams, len(c.params))
-	for k, v := range c.params {
-		params[k] = v
+	wildcards := make([]string, len(c.wildcards))
+	for i, wildcard := range c.wildcards {
+		wildcards[i] = unescapePathValue(wildcard)
 	}
 	c.mu.RUnlock()
-	return params
+	return wildcards
+}
+
+// RawWildcards returns the same values as Wildcards, but as they appear in
+// the escaped request path, without percent-decoding them. A request for
+// "/static/a%2Fb/c" has the raw wildcard "a%2Fb/c", where a request for
+// "/static/a/b/c" has "a/b/c". This is useful for proxies, which need to
+// pass the path on exactly as it was sent. This method is thread-safe and
+// returns a copy of the wildcards.
+func (c *Context) RawWildcards() []string {
+	c.mu.RLock()
+	wildcards := slices.Clone(c.wildcards)
+	c.mu.RUnlock()
+	return wildcards
 }
 
 // Query returns the query parameters of the request.
@@ -546,7 +809,9 @@ func (c *Context) Close() error {
 	return nil
 }
 
-// Deadline returns the deadline of the request. Deadline is part of the go
+// Deadline returns the deadline of the request. It is the earlier of the
+// deadline set on the context, such as by timeout middleware, and the
+// deadline of the request's context. Deadline is part of the go
 // context.Context interface. This method is thread-safe.
 func (c *Context) Deadline() (time.Time, bool) {
 	c.mu.RLock()
@@ -555,38 +820,149 @@ func (c *Context) Deadline() (time.Time, bool) {
 	if ok {
 		deadline = *c.deadline
 	}
+	request := c.request
 	c.mu.RUnlock()
+
+	if request != nil {
+		if requestDeadline, requestOk := request.Context().Deadline(); requestOk && (!ok || requestDeadline.Before(deadline)) {
+			return requestDeadline, true
+		}
+	}
 	return deadline, ok
 }
 
-// Done added for compatibility with go's context.Context.
-// Done is part of the go context.Context interface. Returns a channel that
-// will be closed once the response has been finalized and sent to the
-// client, or if if the request has been aborted. This method is thread-safe.
+// Done returns a channel that is closed once the response has been finalized
+// and sent to the client, when the request's context is done, such as when
+// the client disconnects or the server shuts down, or once the context's
+// deadline passes. This allows the context to be passed to anything that
+// takes a go context.Context, such as database/sql or net/http clients, so
+// they stop when the request does. Done is part of the go context.Context
+// interface. This method is thread-safe.
 func (c *Context) Done() <-chan struct{} {
+	if c.parentContext != nil {
+		return c.parentContext.Done()
+	}
+
 	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.doneChannel == nil {
 		c.doneChannel = make(chan struct{})
+		if c.isDone {
+			close(c.doneChannel)
+		} else {
+			c.watchDone()
+		}
 	}
-	ch := c.doneChannel
-	c.mu.Unlock()
-	return ch
+	return c.doneChannel
 }
 
-// Err returns the final error of the request. Will be nil if the request
-// is still being served even if an error has occurred. Populated once the
-// request is done. Err is part of the go context.Context interface.
-// This method is thread-safe.
+// Err returns nil until the context is done. Once the response has been
+// finalized it returns context.Canceled. If the request's context is done
+// first, its error is returned, and context.DeadlineExceeded is returned once
+// the context's deadline passes. Like any context.Context, it only ever
+// returns context.Canceled or context.DeadlineExceeded; errors set by
+// handlers are available from FinalError instead. Err is part of the go
+// context.Context interface. This method is thread-safe.
 func (c *Context) Err() error {
+	if c.parentContext != nil {
+		return c.parentContext.Err()
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.doneErr != nil {
+		return c.doneErr
+	}
+	if c.isDone {
+		return context.Canceled
+	}
+	if c.request != nil {
+		if err := c.request.Context().Err(); err != nil {
+			return err
+		}
+	}
+	if c.deadline != nil && !time.Now().Before(*c.deadline) {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+// Value returns the value attached to the context with Set for string keys,
+// or with Key.Set for Keys. Other keys, and keys which have not been set,
+// are looked up in the request's context. Value is part of the go
+// context.Context interface. This method is thread-safe.
+func (c *Context) Value(key any) any {
+	if value, ok := c.lookupValue(key); ok {
+		return value
+	}
 	c.mu.RLock()
-	err := c.FinalError
+	request := c.request
 	c.mu.RUnlock()
-	return err
+	if request == nil {
+		return nil
+	}
+	return request.Context().Value(key)
 }
 
-// Value is a noop for compatibility with go's context.Context.
-func (c *Context) Value(any) any {
-	return nil
+// watchDone closes the context's done channel once the request's context is
+// done, or the context's deadline passes, replacing any previous watch. It
+// must be called with the context's lock held, on a context without a
+// parent.
+func (c *Context) watchDone() {
+	c.stopWatchingDone()
+	if c.doneChannel == nil || c.isDone || c.request == nil {
+		return
+	}
+
+	watchedCtx := c.request.Context()
+	cancel := context.CancelFunc(func() {})
+	if c.deadline != nil {
+		watchedCtx, cancel = context.WithDeadline(watchedCtx, *c.deadline)
+	}
+	doneChannel := c.doneChannel
+	stop := context.AfterFunc(watchedCtx, func() {
+		c.closeDone(doneChannel, watchedCtx.Err())
+	})
+	c.stopWatchDone = func() {
+		stop()
+		cancel()
+	}
+}
+
+// stopWatchingDone stops the watch started by watchDone, if any. It must be
+// called with the context's lock held.
+func (c *Context) stopWatchingDone() {
+	if c.stopWatchDone != nil {
+		c.stopWatchDone()
+		c.stopWatchDone = nil
+	}
+}
+
+// closeDone closes the context's done channel with the error that caused it,
+// unless it is already closed. Nothing is done if the context has since been
+// freed, and so no longer has doneChannel.
+func (c *Context) closeDone(doneChannel chan struct{}, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.doneChannel != doneChannel || c.isDone {
+		return
+	}
+	c.isDone = true
+	c.doneErr = err
+	close(doneChannel)
+}
+
+// setDeadline sets the context's deadline, and that of its parents, and
+// updates the done channel's watch to match. A nil deadline removes it.
+func (c *Context) setDeadline(deadline *time.Time) {
+	for current := c; current != nil; current = current.parentContext {
+		current.mu.Lock()
+		current.deadline = deadline
+		if current.parentContext == nil {
+			current.watchDone()
+		}
+		current.mu.Unlock()
+	}
 }
 
 // marshallResponseBody uses a responseBodyMarshaller to marshall the response
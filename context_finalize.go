@@ -2,11 +2,12 @@ package navaros
 
 import (
 	"bytes"
-	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 )
 
@@ -15,11 +16,31 @@ import (
 // libraries which to extend or encapsulate the functionality of Navaros,
 // Finalize can be called with the CtxFinalize function.
 func (c *Context) finalize() {
-	if c.Error != nil {
+	if !c.hasWrittenResponse {
+		c.writeResponse()
+	}
+	c.logHandlerError()
+
+	c.mu.Lock()
+	c.FinalError = c.Error
+	c.FinalErrorStack = c.ErrorStack
+	c.stopWatchingDone()
+	if c.doneChannel != nil && !c.isDone {
+		close(c.doneChannel)
+	}
+	c.isDone = true
+	c.mu.Unlock()
+}
+
+// writeResponse writes the response described by the context's status,
+// headers, and body to the client. It is called by finalize, or earlier by
+// net/http middleware adapted with HTTPMiddleware, so that the middleware can
+// observe the response.
+func (c *Context) writeResponse() {
+	c.hasWrittenResponse = true
+
+	if c.Error != nil && !c.hasHandledError {
 		c.Status = 500
-		if PrintHandlerErrors {
-			fmt.Printf("Error occurred when handling request: %s\n%s", c.Error, c.ErrorStack)
-		}
 	}
 
 	var finalBodyReader io.Reader
@@ -44,9 +65,7 @@ func (c *Context) finalize() {
 					finalBodyReader = marshalledReader
 				} else {
 					c.Status = 500
-					if PrintHandlerErrors {
-						fmt.Printf("Error occurred when marshalling response body: %s", err)
-					}
+					c.logError(slog.LevelError, "error occurred when marshalling response body", err)
 				}
 			}
 		}
@@ -55,7 +74,7 @@ func (c *Context) finalize() {
 	if c.Status == 0 {
 		if redirect != nil {
 			c.Status = 302
-		} else if finalBodyReader == nil {
+		} else if finalBodyReader == nil && !c.hasWrittenBody {
 			c.Status = 404
 		} else {
 			c.Status = 200
@@ -67,6 +86,16 @@ func (c *Context) finalize() {
 		c.Headers.Set("Location", to)
 	}
 
+	// Responses to HEAD requests never have a body, but keep the
+	// Content-Length the body would have had.
+	if c.method == Head && finalBodyReader != nil {
+		contentLength, err := c.discardBody(finalBodyReader)
+		finalBodyReader = nil
+		if err == nil && statusAllowsBody(c.Status) && c.Headers.Get("Content-Length") == "" {
+			c.Headers.Set("Content-Length", strconv.FormatInt(contentLength, 10))
+		}
+	}
+
 	writer := c.bodyWriter
 	if writer == nil {
 		writer = c.responseWriter
@@ -86,37 +115,55 @@ func (c *Context) finalize() {
 	}
 
 	hasBody := finalBodyReader != nil
-	is100Range := c.Status >= 100 && c.Status < 200
-	is204Or304 := c.Status == 204 || c.Status == 304
 
 	if !c.inhibitResponse && hasBody {
-		if is100Range || is204Or304 {
-			fmt.Printf("response with status %d has body but no content is expected", c.Status)
+		if !statusAllowsBody(c.Status) {
+			c.log(slog.LevelWarn, "response has a body but its status does not allow one")
 		} else {
 			_, err := io.Copy(writer, finalBodyReader)
 			if finalBodyReaderCloser, ok := finalBodyReader.(io.Closer); ok {
-				if err := finalBodyReaderCloser.Close(); err != nil && PrintHandlerErrors {
-					fmt.Printf("Failed to close body read closer: %s", err)
+				if err := finalBodyReaderCloser.Close(); err != nil {
+					c.logError(slog.LevelWarn, "failed to close response body reader", err)
 				}
 			}
 			if err != nil {
 				c.Status = 500
-				fmt.Printf("error occurred when writing response body: %s", err)
+				c.logError(slog.LevelWarn, "error occurred when writing response body", err)
 			}
 		}
 	}
 
 	if closer, ok := writer.(io.Closer); ok {
-		if err := closer.Close(); err != nil && PrintHandlerErrors {
-			fmt.Printf("Failed to close body writer: %s", err)
+		if err := closer.Close(); err != nil {
+			c.logError(slog.LevelWarn, "failed to close response body writer", err)
 		}
 	}
+}
 
-	c.FinalError = c.Error
-	c.FinalErrorStack = c.ErrorStack
-	if c.doneChannel != nil {
-		close(c.doneChannel)
+// statusAllowsBody reports whether a response with the given status may
+// have a body.
+func statusAllowsBody(status int) bool {
+	is100Range := status >= 100 && status < 200
+	is204Or304 := status == 204 || status == 304
+	return !is100Range && !is204Or304
+}
+
+// discardBody reads and closes a response body without sending it, returning
+// its length.
+func (c *Context) discardBody(bodyReader io.Reader) (int64, error) {
+	var contentLength int64
+	var err error
+	if lenReader, ok := bodyReader.(interface{ Len() int }); ok {
+		contentLength = int64(lenReader.Len())
+	} else {
+		contentLength, err = io.Copy(io.Discard, bodyReader)
+	}
+	if bodyReadCloser, ok := bodyReader.(io.Closer); ok {
+		if closeErr := bodyReadCloser.Close(); closeErr != nil {
+			c.logError(slog.LevelWarn, "failed to close response body reader", closeErr)
+		}
 	}
+	return contentLength, err
 }
 
 func resolveRedirectLocation(to string, currentPath string) string {
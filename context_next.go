@@ -32,8 +32,8 @@ func (c *Context) Next() {
 	// index and proceed to the next wrap or the real handler. Skip chain
 	// walking.
 	if c.currentHandlerOrTransformer != nil {
-		if c.currentWrapHandlerIndex < len(c.wrapHandlers) {
-			c.currentWrapHandler = c.wrapHandlers[c.currentWrapHandlerIndex]
+		if wrapHandler := c.wrapHandlerAt(c.currentWrapHandlerIndex); wrapHandler != nil {
+			c.currentWrapHandler = wrapHandler
 			c.currentWrapHandlerIndex++
 		}
 	} else {
@@ -52,15 +52,19 @@ func (c *Context) Next() {
 				for c.currentHandlerNode != nil {
 					if len(c.currentHandlerNode.WrapHandlers) > 0 && len(c.currentHandlerNode.HandlersAndTransformers) == 0 {
 						c.wrapHandlers = append(c.wrapHandlers, c.currentHandlerNode.WrapHandlers...)
-						c.currentHandlerNode = c.currentHandlerNode.Next
+						c.advanceHandlerNode()
 						continue
 					}
 					if c.currentHandlerNode.tryMatch(c) {
 						c.currentHandlerNodeMatches = true
 						c.matchedPattern = c.currentHandlerNode.Pattern
+						if c.matchedPattern != nil {
+							c.routePattern = c.matchedPattern
+							c.routePrefix = c.mountPrefix
+						}
 						break
 					}
-					c.currentHandlerNode = c.currentHandlerNode.Next
+					c.advanceHandlerNode()
 				}
 				if !c.currentHandlerNodeMatches {
 					break
@@ -76,9 +80,10 @@ func (c *Context) Next() {
 				c.currentHandlerOrTransformerIndex += 1
 				c.currentWrapHandlerIndex = 0
 
-				// If there are wrap handlers on the context, start the wrap chain.
-				if c.currentWrapHandlerIndex < len(c.wrapHandlers) {
-					c.currentWrapHandler = c.wrapHandlers[c.currentWrapHandlerIndex]
+				// If there are wrap handlers on the context or the handler node,
+				// start the wrap chain.
+				if wrapHandler := c.wrapHandlerAt(c.currentWrapHandlerIndex); wrapHandler != nil {
+					c.currentWrapHandler = wrapHandler
 					c.currentWrapHandlerIndex++
 				}
 
@@ -89,7 +94,7 @@ func (c *Context) Next() {
 			// executed all of it's handlers and transformers. We can now clear the
 			// matching handler node, and continue to the next handler node.
 			c.currentHandlerNodeMatches = false
-			c.currentHandlerNode = c.currentHandlerNode.Next
+			c.advanceHandlerNode()
 			c.currentHandlerOrTransformerIndex = 0
 			c.currentHandlerOrTransformer = nil
 			c.currentWrapHandlerIndex = 0
@@ -140,8 +145,14 @@ func (c *Context) Next() {
 		execWithCtxRecovery(c, func() {
 			currentHandler(c)
 		})
-	} else if _, ok := handlerOrTransformer.(func(res http.ResponseWriter, req *http.Request)); ok {
-		panic("http.HandlerFunc are not yet supported")
+	} else if currentHandler, ok := handlerOrTransformer.(http.Handler); ok {
+		execWithCtxRecovery(c, func() {
+			c.serveHTTPHandler(currentHandler)
+		})
+	} else if currentHandler, ok := handlerOrTransformer.(func(res http.ResponseWriter, req *http.Request)); ok {
+		execWithCtxRecovery(c, func() {
+			c.serveHTTPHandler(http.HandlerFunc(currentHandler))
+		})
 	} else {
 		panic(fmt.Sprintf("Unknown handler type: %s", reflect.TypeOf(handlerOrTransformer)))
 	}
@@ -152,6 +163,25 @@ func (c *Context) Next() {
 	c.currentWrapHandlerIndex = 0
 }
 
+// wrapHandlerAt returns the wrap handler at the given position in the wrap
+// chain of the current handler, or nil if the chain is shorter. The chain is
+// made up of the wrap handlers registered on the context, followed by those
+// of the current handler node if it has both wrap handlers and handlers of
+// its own, as the routes of a group do.
+func (c *Context) wrapHandlerAt(index int) HandlerFunc {
+	if index < len(c.wrapHandlers) {
+		return c.wrapHandlers[index]
+	}
+	index -= len(c.wrapHandlers)
+	if c.currentHandlerNode == nil || len(c.currentHandlerNode.HandlersAndTransformers) == 0 {
+		return nil
+	}
+	if index < len(c.currentHandlerNode.WrapHandlers) {
+		return c.currentHandlerNode.WrapHandlers[index]
+	}
+	return nil
+}
+
 func execWithCtxRecovery(ctx *Context, fn func()) {
 	defer func() {
 		if maybeErr := recover(); maybeErr != nil {
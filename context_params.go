@@ -0,0 +1,180 @@
+package navaros
+
+import "strings"
+
+// contextParams holds the params of a context. The values captured by the
+// route pattern that matched are stored by the pattern's capture indices, so
+// matching a route fills a slice reused across requests rather than a map,
+// and a param is found by name with a single lookup in the pattern's index
+// of param names. Params which don't come from the route's pattern, such as
+// those of host patterns, or those set with CtxSetParam, are held in extra,
+// and take precedence over the pattern's.
+type contextParams struct {
+	pattern *Pattern
+	values  []string
+	extra   []contextParam
+}
+
+// contextParam is a param held outside of the route pattern's values.
+type contextParam struct {
+	key   string
+	value string
+}
+
+func (p *contextParams) resetParams(pattern *Pattern) {
+	clear(p.values)
+	clear(p.extra)
+	p.extra = p.extra[:0]
+	p.pattern = pattern
+	if cap(p.values) < len(pattern.paramKeys) {
+		p.values = make([]string, len(pattern.paramKeys))
+	} else {
+		p.values = p.values[:len(pattern.paramKeys)]
+	}
+}
+
+func (p *contextParams) setParam(index int, _ string, value string) {
+	p.values[index] = value
+}
+
+// reset removes all params, keeping the storage for reuse.
+func (p *contextParams) reset() {
+	clear(p.values)
+	clear(p.extra)
+	p.pattern = nil
+	p.values = p.values[:0]
+	p.extra = p.extra[:0]
+}
+
+// lookup returns the value of the param with exactly the given key.
+func (p *contextParams) lookup(key string) (string, bool) {
+	for _, param := range p.extra {
+		if param.key == key {
+			return param.value, true
+		}
+	}
+	if p.pattern != nil {
+		if index, ok := p.pattern.paramIndexes[key]; ok {
+			return p.values[index], true
+		}
+	}
+	return "", false
+}
+
+// lookupFold returns the value of the first param whose key is equal to the
+// given key case-insensitively.
+func (p *contextParams) lookupFold(key string) (string, bool) {
+	found, value := false, ""
+	p.each(func(k, v string) {
+		if !found && strings.EqualFold(k, key) {
+			found, value = true, v
+		}
+	})
+	return value, found
+}
+
+// each calls fn with each param, skipping those overridden by others.
+func (p *contextParams) each(fn func(key, value string)) {
+	for _, param := range p.extra {
+		fn(param.key, param.value)
+	}
+	if p.pattern == nil {
+		return
+	}
+	for index, key := range p.pattern.paramKeys {
+		if !p.hasExtra(key) {
+			fn(key, p.values[index])
+		}
+	}
+}
+
+// hasExtra reports whether a param with the given key is held in extra.
+func (p *contextParams) hasExtra(key string) bool {
+	for _, param := range p.extra {
+		if param.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// set sets a param, overriding any captured by the route pattern.
+func (p *contextParams) set(key, value string) {
+	for i := range p.extra {
+		if p.extra[i].key == key {
+			p.extra[i].value = value
+			return
+		}
+	}
+	p.extra = append(p.extra, contextParam{key: key, value: value})
+}
+
+// delete removes a param. The route pattern's values are moved to extra
+// first, as they cannot be removed individually.
+func (p *contextParams) delete(key string) {
+	if p.pattern != nil {
+		for index, paramKey := range p.pattern.paramKeys {
+			if !p.hasExtra(paramKey) {
+				p.extra = append(p.extra, contextParam{key: paramKey, value: p.values[index]})
+			}
+		}
+		clear(p.values)
+		p.pattern = nil
+		p.values = p.values[:0]
+	}
+	for i := range p.extra {
+		if p.extra[i].key == key {
+			p.extra = append(p.extra[:i], p.extra[i+1:]...)
+			return
+		}
+	}
+}
+
+// hostParams adds the params captured by a host pattern to a context's
+// params. They're held in extra, as the capture indices of a host pattern
+// don't index the route pattern's values.
+type hostParams contextParams
+
+func (p *hostParams) resetParams(*Pattern) {}
+
+func (p *hostParams) setParam(_ int, key, value string) {
+	(*contextParams)(p).set(key, value)
+}
+
+// Param returns the value of the given route parameter, or an empty string
+// if there is no such parameter. Unlike Params, it doesn't copy the params,
+// making it the cheapest way to read a parameter. Keys are matched exactly,
+// unless the router has case-insensitive params enabled, in which case keys
+// that don't match exactly are compared case-insensitively. This method is
+// thread-safe.
+func (c *Context) Param(key string) string {
+	value, _ := c.lookupParam(key)
+	return value
+}
+
+// lookupParam looks a param up in the context, and then in its parents, so
+// that the params of the routes a router is mounted with are available
+// within it. Params are matched exactly first, then case-insensitively if
+// enabled.
+func (c *Context) lookupParam(key string) (string, bool) {
+	for current := c; current != nil; current = current.parentContext {
+		current.mu.RLock()
+		value, ok := current.params.lookup(key)
+		current.mu.RUnlock()
+		if ok {
+			return value, true
+		}
+	}
+	if !c.foldParamCase {
+		return "", false
+	}
+	for current := c; current != nil; current = current.parentContext {
+		current.mu.RLock()
+		value, ok := current.params.lookupFold(key)
+		current.mu.RUnlock()
+		if ok {
+			return value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,93 @@
+package navaros_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RobertWHurst/navaros"
+)
+
+func TestContextParam(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.Get("/users/:id", func(ctx *navaros.Context) {
+		if ctx.Param("org") != "acme" || ctx.Param("id") != "1" || ctx.Param("tenant") != "eu" {
+			t.Errorf("expected the mount's, host's, and route's params, got %v", ctx.Params())
+		}
+		if ctx.Param("ID") != "" {
+			t.Error("expected params to be case-sensitive by default")
+		}
+		params := ctx.Params()
+		if len(params) != 3 || params["org"] != "acme" || params["id"] != "1" || params["tenant"] != "eu" {
+			t.Errorf("unexpected params %v", params)
+		}
+		ctx.Body = "ok"
+	})
+
+	router := navaros.NewRouter()
+	router.Use("/orgs/:org", subRouter, navaros.WithHost(":tenant.example.com"))
+
+	req := httptest.NewRequest("GET", "/orgs/acme/users/1", nil)
+	req.Host = "eu.example.com"
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Body.String() != "ok" {
+		t.Errorf("expected ok, got %d %s", res.Code, res.Body.String())
+	}
+}
+
+func TestContextParamOptionalAndMixed(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/files/:name.:ext/:version?", func(ctx *navaros.Context) {
+		ctx.Body = ctx.Param("name") + "|" + ctx.Param("ext") + "|" + ctx.Param("version")
+	})
+
+	cases := map[string]string{
+		"/files/report.pdf/2": "report|pdf|2",
+		"/files/archive.zip":  "archive|zip|",
+	}
+	for path, expected := range cases {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest("GET", path, nil))
+		if res.Body.String() != expected {
+			t.Errorf("%s: expected %s, got %s", path, expected, res.Body.String())
+		}
+	}
+}
+
+func TestContextParamCaseInsensitive(t *testing.T) {
+	router := navaros.NewRouter()
+	router.SetCaseInsensitiveParams(true)
+	router.Get("/users/:userId/:UserID", func(ctx *navaros.Context) {
+		ctx.Body = ctx.Param("userid") + " " + ctx.Param("UserID") + " " + ctx.Param("userId")
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/users/1/2", nil))
+	if res.Body.String() != "1 2 1" {
+		t.Errorf("expected exact matches to take precedence, got %s", res.Body.String())
+	}
+}
+
+func TestContextParamTestHelpers(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/users/:id", func(ctx *navaros.Context) {
+		navaros.CtxSetParam(ctx, "id", "2")
+		navaros.CtxSetParam(ctx, "extra", "yes")
+		if ctx.Param("id") != "2" || ctx.Param("extra") != "yes" {
+			t.Errorf("expected set params to override captured ones, got %v", ctx.Params())
+		}
+		navaros.CtxDeleteParam(ctx, "id")
+		if _, ok := ctx.Params()["id"]; ok {
+			t.Error("expected the param to be deleted")
+		}
+		ctx.Body = "ok"
+	})
+
+	for i := 0; i < 2; i++ {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest("GET", "/users/1", nil))
+		if res.Body.String() != "ok" {
+			t.Errorf("expected ok, got %d %s", res.Code, res.Body.String())
+		}
+	}
+}
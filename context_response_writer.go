@@ -4,11 +4,16 @@ import (
 	"bufio"
 	"net"
 	"net/http"
+	"strconv"
 )
 
 type ContextResponseWriter struct {
 	ctx        *Context
 	bodyWriter http.ResponseWriter
+
+	// discardedLength counts the bytes written in response to a HEAD request,
+	// which are discarded rather than sent.
+	discardedLength int64
 }
 
 var _ http.ResponseWriter = &ContextResponseWriter{}
@@ -26,11 +31,23 @@ func (c *ContextResponseWriter) WriteHeader(status int) {
 
 func (c *ContextResponseWriter) Write(bytes []byte) (int, error) {
 	c.ctx.hasWrittenBody = true
+
+	// Bodies are never sent in response to HEAD requests. Instead the bytes
+	// are counted, and the headers held back so a Content-Length can be set
+	// once the status is written.
+	if c.ctx.method == Head {
+		c.discardedLength += int64(len(bytes))
+		return len(bytes), nil
+	}
+
 	c.flushHeaders()
 	return c.bodyWriter.Write(bytes)
 }
 
 func (c *ContextResponseWriter) Flush() {
+	if c.ctx.method == Head && !c.ctx.hasWrittenHeaders {
+		return
+	}
 	if f, ok := c.bodyWriter.(http.Flusher); ok {
 		f.Flush()
 	}
@@ -50,6 +67,11 @@ func (c *ContextResponseWriter) flushHeaders() {
 	}
 	c.ctx.hasWrittenHeaders = true
 
+	if c.ctx.method == Head && c.discardedLength != 0 && statusAllowsBody(c.ctx.Status) &&
+		c.ctx.Headers.Get("Content-Length") == "" {
+		c.ctx.Headers.Set("Content-Length", strconv.FormatInt(c.discardedLength, 10))
+	}
+
 	for key, values := range c.ctx.Headers {
 		for _, value := range values {
 			c.bodyWriter.Header().Add(key, value)
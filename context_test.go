@@ -2,6 +2,7 @@ package navaros_test
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
@@ -674,6 +675,145 @@ func TestContextValue(t *testing.T) {
 	}
 }
 
+type contextTestKey struct{}
+
+func TestContextValueChainsToRequestContext(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test", nil)
+	req = req.WithContext(context.WithValue(req.Context(), contextTestKey{}, "from request"))
+	res := httptest.NewRecorder()
+
+	ctx := navaros.NewContext(res, req, func(ctx *navaros.Context) {
+		ctx.Set("user", "alice")
+		if ctx.Value("user") != "alice" {
+			t.Errorf("expected alice, got %v", ctx.Value("user"))
+		}
+		if ctx.Value(contextTestKey{}) != "from request" {
+			t.Errorf("expected the request context's value, got %v", ctx.Value(contextTestKey{}))
+		}
+	})
+	ctx.Next()
+}
+
+func TestContextDoneOnRequestCancel(t *testing.T) {
+	requestCtx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/test", nil).WithContext(requestCtx)
+	res := httptest.NewRecorder()
+
+	router := navaros.NewRouter()
+	router.Get("/test", func(ctx *navaros.Context) {
+		if ctx.Err() != nil {
+			t.Errorf("expected no error before the request is canceled, got %v", ctx.Err())
+		}
+		cancel()
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Error("expected done to be closed when the request is canceled")
+		}
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", ctx.Err())
+		}
+	})
+	router.ServeHTTP(res, req)
+}
+
+func TestContextDoneInSubRouter(t *testing.T) {
+	requestCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req := httptest.NewRequest("GET", "/sub/test", nil).WithContext(requestCtx)
+	res := httptest.NewRecorder()
+
+	var done <-chan struct{}
+	subRouter := navaros.NewRouter()
+	subRouter.Get("/test", func(ctx *navaros.Context) {
+		done = ctx.Done()
+		ctx.Status = http.StatusNoContent
+	})
+	router := navaros.NewRouter()
+	router.Use("/sub", subRouter)
+	router.ServeHTTP(res, req)
+
+	select {
+	case <-done:
+	default:
+		t.Error("expected the sub router's done channel to be closed once the response is sent")
+	}
+}
+
+func TestContextDeadlineClosesDone(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test", nil)
+	res := httptest.NewRecorder()
+
+	ctx := navaros.NewContext(res, req, nil)
+	done := ctx.Done()
+	navaros.CtxSetDeadline(ctx, time.Now().Add(10*time.Millisecond))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected done to be closed once the deadline passes")
+	}
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", ctx.Err())
+	}
+	navaros.CtxFinalize(ctx)
+	navaros.CtxFree(ctx)
+}
+
+func TestContextDeadlineFromRequest(t *testing.T) {
+	requestDeadline := time.Now().Add(time.Minute)
+	requestCtx, cancel := context.WithDeadline(context.Background(), requestDeadline)
+	defer cancel()
+	req := httptest.NewRequest("GET", "/test", nil).WithContext(requestCtx)
+	res := httptest.NewRecorder()
+
+	ctx := navaros.NewContext(res, req, nil)
+	if deadline, ok := ctx.Deadline(); !ok || !deadline.Equal(requestDeadline) {
+		t.Errorf("expected the request's deadline, got %v", deadline)
+	}
+	navaros.CtxSetDeadline(ctx, requestDeadline.Add(time.Minute))
+	if deadline, _ := ctx.Deadline(); !deadline.Equal(requestDeadline) {
+		t.Errorf("expected the earlier deadline, got %v", deadline)
+	}
+}
+
+func TestContextErrAfterFinalize(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test", nil)
+	res := httptest.NewRecorder()
+
+	ctx := navaros.NewContext(res, req, nil)
+	ctx.Next()
+	navaros.CtxFinalize(ctx)
+
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Errorf("expected context.Canceled once finalized, got %v", ctx.Err())
+	}
+	select {
+	case <-ctx.Done():
+	default:
+		t.Error("expected done to be closed when first requested after finalizing")
+	}
+}
+
+func TestContextErrAfterFinalizeWithHandlerError(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test", nil)
+	res := httptest.NewRecorder()
+
+	handlerErr := errors.New("handler failed")
+	ctx := navaros.NewContext(res, req, func(ctx *navaros.Context) {
+		ctx.Error = handlerErr
+	})
+	ctx.Next()
+	navaros.CtxFinalize(ctx)
+
+	if ctx.Err() != context.Canceled {
+		t.Errorf("expected context.Canceled rather than the handler error, got %v", ctx.Err())
+	}
+	if !errors.Is(ctx.FinalError, handlerErr) {
+		t.Errorf("expected the handler error in FinalError, got %v", ctx.FinalError)
+	}
+}
+
 func TestContextResponseWriterFlushesHeadersOnWrite(t *testing.T) {
 	req := httptest.NewRequest("GET", "/test", nil)
 	res := httptest.NewRecorder()
@@ -873,7 +1013,8 @@ func TestContextResponseBodyMarshallerError(t *testing.T) {
 }
 
 func TestContextNewContextWithNodeError(t *testing.T) {
-	req := httptest.NewRequest("INVALID", "/test", nil)
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Method = "IN VALID"
 	res := httptest.NewRecorder()
 
 	ctx := navaros.NewContext(res, req, nil)
@@ -900,3 +1041,15 @@ func TestContextRequestBodyReaderMaxSize(t *testing.T) {
 	})
 	ctx.Next()
 }
+
+func TestContextMustGetPanics(t *testing.T) {
+	ctx := navaros.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	defer navaros.CtxFree(ctx)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected MustGet to panic for a missing value")
+		}
+	}()
+	ctx.MustGet("missing")
+}
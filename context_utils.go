@@ -10,12 +10,12 @@ import (
 // path, it will have no parameters. This function allows tests to
 // set parameters on the context.
 func CtxSetParam(ctx *Context, key, value string) {
-	ctx.params[key] = value
+	ctx.params.set(key, value)
 }
 
 // CtxDeleteParam allows tests to delete a parameter from the context.
 func CtxDeleteParam(ctx *Context, key string) {
-	delete(ctx.params, key)
+	ctx.params.delete(key)
 }
 
 // CtxFinalize allows libraries to call the finalize method on a context.
@@ -35,7 +35,7 @@ func CtxFree(ctx *Context) {
 // CtxSetDeadline sets a deadline for the context. This allows libraries to
 // limit the amount of time a handler can take to process a request.
 func CtxSetDeadline(ctx *Context, deadline time.Time) {
-	ctx.deadline = &deadline
+	ctx.setDeadline(&deadline)
 }
 
 // CtxInhibitResponse prevents the context from sending a response. This is
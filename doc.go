@@ -19,7 +19,7 @@
 //
 // # Features
 //
-// - Sequential pattern matching with regex for dynamic segments
+// - Sequential pattern matching, indexed by static path prefix
 // - Middleware chain with Next() for pre/post processing
 // - Parameter extraction with wildcards, regex, optional/greedy modifiers
 // - JSON body parsing via middleware
@@ -45,9 +45,12 @@
 //
 // - Static: /users
 // - Param: /users/:id
-// - Wildcard: /files/*
+// - Wildcard: /files/*, /files/** (values from ctx.Wildcards())
+// - Named wildcard: /static/**path
 // - Optional: /users/:id?
 // - Regex: /users/:id(\\d+)
+// - Mixed: /files/:name.:ext, /@:username
+// - Typed: /users/:id<int> (int, uint, float, alpha, alnum, uuid, date, or RegisterParamType)
 // - Combine: /api/:v(\\d+)/:action*/static
 //
 // Specific patterns before general.
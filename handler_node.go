@@ -6,20 +6,50 @@ package navaros
 type HandlerNode struct {
 	Method                  HTTPMethod
 	Pattern                 *Pattern
+	Host                    *Pattern
+	Name                    string
+	Version                 string
 	HandlersAndTransformers []any
 	WrapHandlers            []HandlerFunc
 	Next                    *HandlerNode
+
+	// seq is the node's registration order within its router. It is used to
+	// keep handler nodes found through the route tree in order.
+	seq int
+
+	// isPublic and metadata are used to build the router's route descriptors.
+	isPublic bool
+	metadata any
+
+	// callSite is the file and line the node was bound at, if known. It is
+	// used to point at conflicting routes when validating a router.
+	callSite string
 }
 
 // tryMatch attempts to match the handler node's route pattern and http
 // method to the a context. It will return true if the handler node
-// matches, and false if it does not.
+// matches, and false if it does not. GET nodes also match HEAD requests
+// when the router has determined the request should fall back to them.
+// Nodes with a host pattern only match requests for a matching host, and
+// add the host's parameters to those of the path. Nodes bound to an API
+// version only match requests for that version.
 func (n *HandlerNode) tryMatch(ctx *Context) bool {
-	if n.Method != All && n.Method != ctx.method {
+	if n.Method != All && n.Method != ctx.method && (n.Method != Get || !ctx.headMatchesGet) {
+		return false
+	}
+	if n.Host != nil && !n.Host.matchesPath(ctx.host, 0) {
+		return false
+	}
+	if !ctx.acceptsAPIVersion(n.Version) {
 		return false
 	}
-	if n.Pattern == nil {
-		return true
+	if n.Pattern != nil {
+		if !n.Pattern.matchInto(ctx.path, &ctx.params, &ctx.wildcards, ctx.matchFlags) {
+			return false
+		}
+	}
+	if n.Host != nil {
+		n.Host.addMatchedParams(ctx.host, (*hostParams)(&ctx.params))
 	}
-	return n.Pattern.MatchInto(ctx.path, &ctx.params)
+	return true
 }
@@ -0,0 +1,116 @@
+package navaros
+
+import (
+	"context"
+	"net/http"
+)
+
+// requestContextKey is the key the navaros context is stored under in the
+// context of requests passed to net/http handlers.
+type requestContextKey struct{}
+
+// requestContext is the context of requests passed to net/http handlers and
+// middleware. It carries the navaros context, and values attached to it can
+// be looked up with their string keys or Keys.
+type requestContext struct {
+	context.Context
+	ctx *Context
+}
+
+// Value returns the navaros context for requestContextKey, values attached to
+// the navaros context for string keys and Keys, and otherwise the value of
+// the request's original context.
+func (c requestContext) Value(key any) any {
+	if _, ok := key.(requestContextKey); ok {
+		return c.ctx
+	}
+	if value, ok := c.ctx.lookupValue(key); ok {
+		return value
+	}
+	return c.Context.Value(key)
+}
+
+// ContextFromRequest returns the navaros context of a request passed to a
+// net/http handler or middleware by a router. False is returned if the
+// request did not come from a router.
+func ContextFromRequest(req *http.Request) (*Context, bool) {
+	ctx, ok := req.Context().Value(requestContextKey{}).(*Context)
+	return ctx, ok
+}
+
+// httpRequest returns the request to pass to net/http handlers. The route
+// params are available through PathValue, the navaros context and its values
+// through the request's context, and the body is limited to the context's
+// max request body size.
+func (c *Context) httpRequest() *http.Request {
+	req := c.request.WithContext(requestContext{Context: c.request.Context(), ctx: c})
+	for key, value := range c.Params() {
+		req.SetPathValue(key, value)
+	}
+	if req.Body != nil {
+		req.Body = c.RequestBodyReader()
+	}
+	return req
+}
+
+// serveHTTPHandler runs a net/http handler bound to a route. Like net/http,
+// a handler which doesn't write a response is treated as responding with an
+// empty 200 OK.
+func (c *Context) serveHTTPHandler(handler http.Handler) {
+	handler.ServeHTTP(c.ResponseWriter(), c.httpRequest())
+	if c.Status == 0 && c.Body == nil && !c.hasWrittenHeaders {
+		c.Status = http.StatusOK
+	}
+}
+
+// setRequest replaces the context's request.
+func (c *Context) setRequest(req *http.Request) {
+	c.mu.Lock()
+	c.request = req
+	c.mu.Unlock()
+}
+
+// HTTPMiddleware adapts net/http middleware, such as tracing or auth
+// middleware written for the standard library, so it can be used with Use or
+// bound to routes like any other handler. The middleware is constructed once,
+// when HTTPMiddleware is called, so state it sets up, such as a rate limiter
+// or a tracer, is shared by every request. The request passed to the
+// middleware is prepared as it is for net/http handlers. When the middleware
+// calls the next handler, the rest of the handler chain runs with the request
+// and response writer it passed on, so values it adds to the request context
+// are available through ctx.Request().Context(). The request it passes on
+// must keep the context it was given, or be derived from it.
+//
+// So that the middleware can observe the response, it is written before the
+// next handler returns, unless no handler responded, in which case the
+// router's own response, such as a 404, is written once the middleware
+// returns. Handlers bound before the middleware therefore cannot change the
+// response after calling ctx.Next().
+func HTTPMiddleware(middleware func(http.Handler) http.Handler) HandlerFunc {
+	if middleware == nil {
+		panic("no middleware provided")
+	}
+	handler := middleware(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		ctx, ok := ContextFromRequest(req)
+		if !ok {
+			panic("net/http middleware must pass on a request derived from the one it was given")
+		}
+
+		request, bodyWriter := ctx.request, ctx.bodyWriter
+		defer func() {
+			ctx.setRequest(request)
+			ctx.bodyWriter = bodyWriter
+		}()
+		ctx.setRequest(req)
+		ctx.bodyWriter = res
+
+		ctx.Next()
+		if !ctx.isUnhandled() && !ctx.hasWrittenResponse {
+			ctx.handleError()
+			ctx.writeResponse()
+		}
+	}))
+	return func(ctx *Context) {
+		handler.ServeHTTP(ctx.ResponseWriter(), ctx.httpRequest())
+	}
+}
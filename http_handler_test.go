@@ -0,0 +1,189 @@
+package navaros_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/RobertWHurst/navaros"
+)
+
+func TestRouterHTTPHandler(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Use(func(ctx *navaros.Context) {
+		ctx.Set("user", "alice")
+		ctx.Headers.Set("X-Navaros", "yes")
+		ctx.Next()
+	})
+	router.Get("/users/:id", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		user, _ := req.Context().Value("user").(string)
+		res.Header().Set("Content-Type", "text/plain")
+		res.WriteHeader(http.StatusAccepted)
+		io.WriteString(res, req.PathValue("id")+" "+user)
+	}))
+	router.Post("/echo", func(res http.ResponseWriter, req *http.Request) {
+		ctx, ok := navaros.ContextFromRequest(req)
+		if !ok {
+			t.Error("expected the navaros context in the request context")
+			return
+		}
+		body, _ := io.ReadAll(req.Body)
+		ctx.Body = string(body)
+	})
+	router.Delete("/empty", func(res http.ResponseWriter, req *http.Request) {})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/users/1", nil))
+	if res.Code != http.StatusAccepted || res.Body.String() != "1 alice" {
+		t.Errorf("expected 202 1 alice, got %d %s", res.Code, res.Body.String())
+	}
+	if res.Header().Get("X-Navaros") != "yes" || res.Header().Get("Content-Type") != "text/plain" {
+		t.Errorf("expected both the context and handler headers, got %v", res.Header())
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("POST", "/echo", strings.NewReader("hello")))
+	if res.Body.String() != "hello" {
+		t.Errorf("expected hello, got %d %s", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("DELETE", "/empty", nil))
+	if res.Code != http.StatusOK {
+		t.Errorf("expected handlers which don't respond to be treated as 200, got %d", res.Code)
+	}
+}
+
+func TestRouterHTTPHandlerBodyLimit(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Use(func(ctx *navaros.Context) {
+		ctx.MaxRequestBodySize = 4
+		ctx.Next()
+	})
+	router.Post("/upload", func(res http.ResponseWriter, req *http.Request) {
+		if _, err := io.ReadAll(req.Body); err == nil {
+			t.Error("expected the body to be limited")
+		}
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/upload", strings.NewReader("too long")))
+}
+
+type requestIDKey struct{}
+
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		ctx := context.WithValue(req.Context(), requestIDKey{}, "req-1")
+		next.ServeHTTP(res, req.WithContext(ctx))
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	var recordedStatus int
+	statusMiddleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			recorder := &statusRecorder{ResponseWriter: res}
+			next.ServeHTTP(recorder, req)
+			recordedStatus = recorder.status
+		})
+	}
+	authMiddleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("Authorization") == "" {
+				http.Error(res, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(res, req)
+		})
+	}
+
+	router := navaros.NewRouter()
+	router.Use(navaros.HTTPMiddleware(statusMiddleware))
+	router.Use(navaros.HTTPMiddleware(requestIDMiddleware))
+	router.Use("/private", navaros.HTTPMiddleware(authMiddleware))
+	router.Get("/items/:id", func(ctx *navaros.Context) {
+		requestID, _ := ctx.Request().Context().Value(requestIDKey{}).(string)
+		ctx.Status = http.StatusCreated
+		ctx.Body = ctx.Params().Get("id") + " " + requestID
+	})
+	router.Get("/private/data", func(ctx *navaros.Context) {
+		ctx.Body = "secret"
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/items/5", nil))
+	if res.Code != http.StatusCreated || res.Body.String() != "5 req-1" {
+		t.Errorf("expected 201 5 req-1, got %d %s", res.Code, res.Body.String())
+	}
+	if recordedStatus != http.StatusCreated {
+		t.Errorf("expected the middleware to observe the status, got %d", recordedStatus)
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/private/data", nil))
+	if res.Code != http.StatusUnauthorized || strings.TrimSpace(res.Body.String()) != "unauthorized" {
+		t.Errorf("expected 401 unauthorized, got %d %s", res.Code, res.Body.String())
+	}
+
+	req := httptest.NewRequest("GET", "/private/data", nil)
+	req.Header.Set("Authorization", "token")
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Body.String() != "secret" {
+		t.Errorf("expected secret, got %d %s", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("POST", "/items/5", nil))
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected the router's own response when nothing handles the request, got %d", res.Code)
+	}
+}
+
+func TestHTTPMiddlewareIsConstructedOnce(t *testing.T) {
+	constructions := 0
+	requestCount := 0
+	countingMiddleware := func(next http.Handler) http.Handler {
+		constructions += 1
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			requestCount += 1
+			next.ServeHTTP(res, req)
+		})
+	}
+
+	router := navaros.NewRouter()
+	router.Use(navaros.HTTPMiddleware(countingMiddleware))
+	router.Get("/items/:id", func(ctx *navaros.Context) {
+		ctx.Body = ctx.Params().Get("id")
+	})
+
+	for i := 0; i < 3; i += 1 {
+		id := strconv.Itoa(i)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest("GET", "/items/"+id, nil))
+		if res.Body.String() != id {
+			t.Errorf("expected %s, got %s", id, res.Body.String())
+		}
+	}
+
+	if constructions != 1 {
+		t.Errorf("expected the middleware to be constructed once, got %d", constructions)
+	}
+	if requestCount != 3 {
+		t.Errorf("expected the middleware to run for 3 requests, got %d", requestCount)
+	}
+}
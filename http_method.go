@@ -25,9 +25,17 @@ const (
 	Options HTTPMethod = "OPTIONS"
 	// Head represents the HTTP HEAD method.
 	Head HTTPMethod = "HEAD"
+	// Connect represents the HTTP CONNECT method.
+	Connect HTTPMethod = "CONNECT"
+	// Trace represents the HTTP TRACE method.
+	Trace HTTPMethod = "TRACE"
+	// Query represents the HTTP QUERY method.
+	Query HTTPMethod = "QUERY"
 )
 
-// HTTPMethodFromString converts a string to an HTTPMethod.
+// HTTPMethodFromString converts a string to an HTTPMethod. Any method that is
+// a valid token as defined by RFC 9110 is accepted, including extension
+// methods such as WebDAV's PROPFIND. Methods are normalized to upper case.
 // If the string is not a valid HTTP method, an error is returned.
 func HTTPMethodFromString(method string) (HTTPMethod, error) {
 	switch strings.ToUpper(method) {
@@ -47,7 +55,34 @@ func HTTPMethodFromString(method string) (HTTPMethod, error) {
 		return Options, nil
 	case "HEAD":
 		return Head, nil
-	default:
+	case "CONNECT":
+		return Connect, nil
+	case "TRACE":
+		return Trace, nil
+	case "QUERY":
+		return Query, nil
+	}
+
+	if !isHTTPToken(method) {
 		return All, errors.New("invalid http method `" + method + "`")
 	}
+	return HTTPMethod(strings.ToUpper(method)), nil
+}
+
+// isHTTPToken reports whether str is a token as defined by RFC 9110, section
+// 5.6.2. Method names must be tokens.
+func isHTTPToken(str string) bool {
+	if str == "" {
+		return false
+	}
+	for i := 0; i < len(str); i += 1 {
+		char := str[i]
+		if char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char >= '0' && char <= '9' {
+			continue
+		}
+		if !strings.ContainsRune("!#$%&'*+-.^_`|~", rune(char)) {
+			return false
+		}
+	}
+	return true
 }
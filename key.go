@@ -0,0 +1,86 @@
+package navaros
+
+import "fmt"
+
+// Key is a typed key for values attached to a context. Unlike the string
+// keys used with Set and Get, values attached with a Key are retrieved with
+// their type, and a typo in a key is a compile error rather than a missing
+// value.
+//
+// Keys are compared by identity, so every call to NewKey creates a distinct
+// key, even for the same name and type. Declare keys once, usually as
+// package level variables, and share them between the handlers that set and
+// get their values:
+//
+//	var UserKey = navaros.NewKey[*User]("user")
+//
+//	UserKey.Set(ctx, user)
+//	user := UserKey.MustGet(ctx)
+type Key[T any] struct {
+	name string
+}
+
+// contextKey is implemented by Keys of every type, so they can be told apart
+// from other keys passed to Value.
+type contextKey interface {
+	isContextKey()
+}
+
+func (*Key[T]) isContextKey() {}
+
+// NewKey creates a Key for values of type T. The name is only used to
+// describe the key, such as in the panic of MustGet.
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+// String returns the name of the key. It's implemented to satisfy the
+// fmt.Stringer interface.
+func (k *Key[T]) String() string {
+	return k.name
+}
+
+// Set attaches a value to the context under the key. It can later be
+// retrieved with Get or MustGet. This method is thread-safe.
+func (k *Key[T]) Set(ctx *Context, value T) {
+	ctx.setValue(k, value)
+}
+
+// Get retrieves the value attached to the context under the key. False is
+// returned if no value has been set. This method is thread-safe.
+func (k *Key[T]) Get(ctx *Context) (T, bool) {
+	v, ok := ctx.getValue(k)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	value, _ := v.(T)
+	return value, true
+}
+
+// MustGet retrieves the value attached to the context under the key. It
+// panics if no value has been set. This method is thread-safe.
+func (k *Key[T]) MustGet(ctx *Context) T {
+	value, ok := k.Get(ctx)
+	if !ok {
+		panic(fmt.Sprintf("no value set on the context for key %s", k.name))
+	}
+	return value
+}
+
+// Delete removes the value attached to the context under the key. This
+// method is thread-safe.
+func (k *Key[T]) Delete(ctx *Context) {
+	ctx.deleteValue(k)
+}
+
+// lookupValue retrieves a value attached to the context if key is a string
+// or a Key. Other keys are never attached to the context, and are not looked
+// up, as they may not be comparable.
+func (c *Context) lookupValue(key any) (any, bool) {
+	switch key.(type) {
+	case string, contextKey:
+		return c.getValue(key)
+	}
+	return nil, false
+}
@@ -0,0 +1,87 @@
+package navaros_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RobertWHurst/navaros"
+)
+
+type keyTestUser struct {
+	Name string
+}
+
+var keyTestUserKey = navaros.NewKey[*keyTestUser]("user")
+
+func TestKey(t *testing.T) {
+	countKey := navaros.NewKey[int]("count")
+	otherCountKey := navaros.NewKey[int]("count")
+
+	router := navaros.NewRouter()
+	router.Use(func(ctx *navaros.Context) {
+		keyTestUserKey.Set(ctx, &keyTestUser{Name: "alice"})
+		countKey.Set(ctx, 1)
+		ctx.Next()
+	})
+	router.Get("/", func(ctx *navaros.Context) {
+		if user := keyTestUserKey.MustGet(ctx); user.Name != "alice" {
+			t.Errorf("expected alice, got %s", user.Name)
+		}
+		if count, ok := countKey.Get(ctx); !ok || count != 1 {
+			t.Errorf("expected 1, got %d %v", count, ok)
+		}
+		if _, ok := otherCountKey.Get(ctx); ok {
+			t.Error("expected keys with the same name and type to be distinct")
+		}
+		if ctx.Value(countKey) != 1 {
+			t.Errorf("expected Value to look up keys, got %v", ctx.Value(countKey))
+		}
+		if _, ok := ctx.Get("count"); ok {
+			t.Error("expected keys not to collide with string keys")
+		}
+
+		countKey.Delete(ctx)
+		if _, ok := countKey.Get(ctx); ok {
+			t.Error("expected the value to be deleted")
+		}
+		ctx.Body = "ok"
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+	if res.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", res.Code)
+	}
+}
+
+func TestKeyMustGetPanics(t *testing.T) {
+	ctx := navaros.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	defer navaros.CtxFree(ctx)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected MustGet to panic for a missing value")
+		}
+	}()
+	keyTestUserKey.MustGet(ctx)
+}
+
+func TestKeyValuesAreResetBetweenRequests(t *testing.T) {
+	countKey := navaros.NewKey[int]("count")
+
+	router := navaros.NewRouter()
+	router.Get("/set", func(ctx *navaros.Context) {
+		countKey.Set(ctx, 1)
+		ctx.Body = "ok"
+	})
+	router.Get("/check", func(ctx *navaros.Context) {
+		if _, ok := countKey.Get(ctx); ok {
+			t.Error("expected the value not to persist across requests")
+		}
+		ctx.Body = "ok"
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/set", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/check", nil))
+}
@@ -0,0 +1,105 @@
+package navaros
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// defaultLogger holds the logger set with SetDefaultLogger.
+var defaultLogger atomic.Pointer[slog.Logger]
+
+// SetDefaultLogger sets the logger used by routers and contexts without a
+// logger of their own, set with the router's SetLogger method. Setting it to
+// nil restores the default of slog.Default(). This function is thread-safe.
+func SetDefaultLogger(logger *slog.Logger) {
+	defaultLogger.Store(logger)
+}
+
+// DefaultLogger returns the logger set with SetDefaultLogger, or
+// slog.Default() if none has been set.
+func DefaultLogger() *slog.Logger {
+	if logger := defaultLogger.Load(); logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
+
+// SetLogger sets the logger the router reports diagnostics to, such as
+// handler errors and failures to encode or write response bodies. Routers
+// without a logger use the package default, set with SetDefaultLogger.
+// Handler errors within a mounted router with its own logger are reported to
+// it, and all other diagnostics to the logger of the router serving the
+// request.
+//
+// Records carry the request's method and path, the pattern of the route that
+// matched last, the response status, and where relevant the error and its
+// stack. Handler errors are logged at the error level, and other failures at
+// the warn level.
+func (r *Router) SetLogger(logger *slog.Logger) {
+	r.logger = logger
+}
+
+// log reports a diagnostic about the context's request to its logger, with
+// the attributes describing the request followed by attrs.
+func (c *Context) log(level slog.Level, msg string, attrs ...slog.Attr) {
+	logger := c.logger
+	if logger == nil {
+		logger = DefaultLogger()
+	}
+	ctx := context.Background()
+	if c.request != nil {
+		ctx = c.request.Context()
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	pattern := ""
+	if c.routePattern != nil {
+		pattern = c.routePrefix + c.routePattern.String()
+	}
+	// Errors not rendered by an error handler are always responded to with a
+	// 500, even if the status has yet to be set.
+	status := c.Status
+	if c.Error != nil && !c.hasHandledError {
+		status = http.StatusInternalServerError
+	}
+	requestAttrs := []slog.Attr{
+		slog.String("method", string(c.method)),
+		slog.String("path", c.rootContext().path),
+		slog.String("pattern", pattern),
+		slog.Int("status", status),
+	}
+	logger.LogAttrs(ctx, level, msg, append(requestAttrs, attrs...)...)
+}
+
+// logError reports a diagnostic with an error to the context's logger.
+func (c *Context) logError(level slog.Level, msg string, err error) {
+	c.log(level, msg, slog.Any("error", err))
+}
+
+// logHandlerError reports the error set by the context's handlers, with its
+// stack if it came from a panic, unless it has already been reported.
+func (c *Context) logHandlerError() {
+	if c.Error == nil || c.hasLoggedError {
+		return
+	}
+	c.hasLoggedError = true
+	attrs := []slog.Attr{slog.Any("error", c.Error)}
+	if c.ErrorStack != "" {
+		attrs = append(attrs, slog.String("stack", c.ErrorStack))
+	}
+	c.log(slog.LevelError, "error occurred when handling request", attrs...)
+}
+
+// mountPathPrefix returns the path a router mounted with the given pattern
+// is mounted at, so the patterns of its routes can be logged in full.
+func mountPathPrefix(pattern *Pattern) string {
+	if pattern == nil || !pattern.endsWithMountWildcard() {
+		return ""
+	}
+	return strings.TrimSuffix(pattern.String(), "/**")
+}
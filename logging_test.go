@@ -0,0 +1,144 @@
+package navaros_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RobertWHurst/navaros"
+)
+
+func newTestLogger() (*slog.Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	return slog.New(slog.NewJSONHandler(buf, nil)), buf
+}
+
+func readLogRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	records := []map[string]any{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		record := map[string]any{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestRouterSetLogger(t *testing.T) {
+	logger, buf := newTestLogger()
+	router := navaros.NewRouter()
+	router.SetLogger(logger)
+	router.Get("/users/:id", func(ctx *navaros.Context) {
+		panic("boom")
+	})
+	router.Get("/teams/:id", func(ctx *navaros.Context) {
+		ctx.Error = errors.New("failed")
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/teams/2", nil))
+
+	records := readLogRecords(t, buf)
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	record := records[0]
+	if record["level"] != "ERROR" {
+		t.Errorf("expected an error record, got %v", record["level"])
+	}
+	if record["method"] != "GET" || record["path"] != "/users/1" || record["pattern"] != "/users/:id" {
+		t.Errorf("expected the request to be described, got %v", record)
+	}
+	if record["status"] != float64(500) || record["error"] != "boom" {
+		t.Errorf("expected a 500 with the error, got %v", record)
+	}
+	if stack, _ := record["stack"].(string); stack == "" {
+		t.Error("expected the panic's stack")
+	}
+	if records[1]["error"] != "failed" || records[1]["pattern"] != "/teams/:id" {
+		t.Errorf("expected the second error, got %v", records[1])
+	}
+	if _, ok := records[1]["stack"]; ok {
+		t.Error("expected no stack for an error which did not come from a panic")
+	}
+}
+
+func TestRouterSetLoggerInSubRouter(t *testing.T) {
+	logger, buf := newTestLogger()
+	subLogger, subBuf := newTestLogger()
+
+	subRouter := navaros.NewRouter()
+	subRouter.SetLogger(subLogger)
+	subRouter.Get("/users/:id", func(ctx *navaros.Context) {
+		ctx.Error = errors.New("failed")
+	})
+	subRouter.Get("/empty", func(ctx *navaros.Context) {
+		ctx.Status = 204
+		ctx.Body = "unexpected"
+	})
+
+	router := navaros.NewRouter()
+	router.SetLogger(logger)
+	router.Use("/api", subRouter)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/api/users/1", nil))
+	if res.Code != 500 {
+		t.Errorf("expected 500, got %d", res.Code)
+	}
+
+	subRecords := readLogRecords(t, subBuf)
+	if len(subRecords) != 1 {
+		t.Fatalf("expected the sub router to log 1 record, got %d", len(subRecords))
+	}
+	if subRecords[0]["pattern"] != "/api/users/:id" || subRecords[0]["path"] != "/api/users/1" {
+		t.Errorf("expected the full pattern and path, got %v", subRecords[0])
+	}
+	if records := readLogRecords(t, buf); len(records) != 0 {
+		t.Errorf("expected the error to be logged once, got %v", records)
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/empty", nil))
+	records := readLogRecords(t, buf)
+	if len(records) != 1 {
+		t.Fatalf("expected the router to log 1 record, got %d", len(records))
+	}
+	if records[0]["level"] != "WARN" || records[0]["status"] != float64(204) || records[0]["pattern"] != "/api/empty" {
+		t.Errorf("expected a warning about the body, got %v", records[0])
+	}
+}
+
+func TestSetDefaultLogger(t *testing.T) {
+	logger, buf := newTestLogger()
+	navaros.SetDefaultLogger(logger)
+	defer navaros.SetDefaultLogger(nil)
+
+	if navaros.DefaultLogger() != logger {
+		t.Error("expected the default logger to be set")
+	}
+
+	router := navaros.NewRouter()
+	router.Get("/", func(ctx *navaros.Context) {
+		ctx.Error = errors.New("failed")
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	records := readLogRecords(t, buf)
+	if len(records) != 1 || records[0]["error"] != "failed" {
+		t.Errorf("expected the error to be logged to the default logger, got %v", records)
+	}
+
+	navaros.SetDefaultLogger(nil)
+	if navaros.DefaultLogger() != slog.Default() {
+		t.Error("expected the default logger to be restored")
+	}
+}
@@ -75,3 +75,25 @@ func TestMiddlewareValueDoesNotPersistAcrossRequests(t *testing.T) {
 	w2 := httptest.NewRecorder()
 	router.ServeHTTP(w2, req2)
 }
+
+func TestKeyMiddleware(t *testing.T) {
+	versionKey := navaros.NewKey[string]("apiVersion")
+	router := navaros.NewRouter()
+	router.Use(set.KeyMiddleware(versionKey, "v1"))
+
+	router.Get("/test", func(ctx *navaros.Context) {
+		if version := versionKey.MustGet(ctx); version != "v1" {
+			t.Errorf("expected 'v1', got %s", version)
+		}
+		ctx.Status = http.StatusOK
+		ctx.Body = "ok"
+	})
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+}
@@ -8,3 +8,12 @@ func Middleware[V any](key string, valueFn func() V) func(ctx *navaros.Context)
 		ctx.Next()
 	}
 }
+
+// KeyMiddleware is Middleware for a typed key. It calls valueFn for each
+// request and attaches the result to its context under the key.
+func KeyMiddleware[V any](key *navaros.Key[V], valueFn func() V) func(ctx *navaros.Context) {
+	return func(ctx *navaros.Context) {
+		key.Set(ctx, valueFn())
+		ctx.Next()
+	}
+}
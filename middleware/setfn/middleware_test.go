@@ -71,3 +71,27 @@ func TestMiddlewareFunctionCalledPerRequest(t *testing.T) {
 		t.Errorf("expected function to be called twice, got %d", callCount)
 	}
 }
+
+func TestKeyMiddleware(t *testing.T) {
+	requestIDKey := navaros.NewKey[string]("requestID")
+	router := navaros.NewRouter()
+	router.Use(setfn.KeyMiddleware(requestIDKey, func() string {
+		return "req-123"
+	}))
+
+	router.Get("/test", func(ctx *navaros.Context) {
+		if requestID := requestIDKey.MustGet(ctx); requestID != "req-123" {
+			t.Errorf("expected 'req-123', got %s", requestID)
+		}
+		ctx.Status = http.StatusOK
+		ctx.Body = "ok"
+	})
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+}
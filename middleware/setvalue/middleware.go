@@ -8,3 +8,12 @@ func Middleware[V any](key string, value *V) func(ctx *navaros.Context) {
 		ctx.Next()
 	}
 }
+
+// KeyMiddleware is Middleware for a typed key. It attaches the value value
+// points to at the time of each request to its context under the key.
+func KeyMiddleware[V any](key *navaros.Key[V], value *V) func(ctx *navaros.Context) {
+	return func(ctx *navaros.Context) {
+		key.Set(ctx, *value)
+		ctx.Next()
+	}
+}
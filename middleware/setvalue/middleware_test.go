@@ -72,3 +72,28 @@ func TestMiddlewareStoresValueNotPointer(t *testing.T) {
 		t.Errorf("expected status 200, got %d", w.Code)
 	}
 }
+
+func TestKeyMiddleware(t *testing.T) {
+	timeoutKey := navaros.NewKey[int]("timeout")
+	timeout := 30
+	router := navaros.NewRouter()
+	router.Use(setvalue.KeyMiddleware(timeoutKey, &timeout))
+
+	router.Get("/test", func(ctx *navaros.Context) {
+		if val := timeoutKey.MustGet(ctx); val != 60 {
+			t.Errorf("expected current dereferenced value 60, got %d", val)
+		}
+		ctx.Status = http.StatusOK
+		ctx.Body = "ok"
+	})
+
+	timeout = 60
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+}
@@ -0,0 +1,201 @@
+package navaros
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DateParamLayout is the layout used by the date parameter type, and by the
+// Date method of RequestParams.
+const DateParamLayout = time.DateOnly
+
+var paramTypesMu sync.RWMutex
+
+// customParamTypes holds the names of the types registered with
+// RegisterParamType, including built in types which have been replaced.
+var customParamTypes = map[string]bool{}
+
+var paramTypes = map[string]func(value string) bool{
+	"int": func(value string) bool {
+		// Values are limited to the size of an int, so that any value which
+		// matches can be read with RequestParams.Int.
+		_, err := strconv.ParseInt(value, 10, strconv.IntSize)
+		return err == nil
+	},
+	"uint": func(value string) bool {
+		_, err := strconv.ParseUint(value, 10, 64)
+		return err == nil
+	},
+	"float": func(value string) bool {
+		_, err := parseDecimalFloat(value)
+		return err == nil
+	},
+	"alpha": func(value string) bool {
+		for i := 0; i < len(value); i += 1 {
+			if !isAlpha(value[i]) {
+				return false
+			}
+		}
+		return true
+	},
+	"alnum": func(value string) bool {
+		for i := 0; i < len(value); i += 1 {
+			if !isAlpha(value[i]) && !isDigit(value[i]) {
+				return false
+			}
+		}
+		return true
+	},
+	"uuid": func(value string) bool {
+		if len(value) != 36 {
+			return false
+		}
+		for i := 0; i < len(value); i += 1 {
+			switch i {
+			case 8, 13, 18, 23:
+				if value[i] != '-' {
+					return false
+				}
+			default:
+				if !isHexDigit(value[i]) {
+					return false
+				}
+			}
+		}
+		return true
+	},
+	"date": func(value string) bool {
+		_, err := time.Parse(DateParamLayout, value)
+		return err == nil
+	},
+}
+
+// RegisterParamType registers a named type that can be used to constrain
+// route parameters, as in "/users/:id<int>". The check function is given the
+// value of a path segment and reports whether it is valid for the type. If it
+// is not, the route does not match and the request moves on to the next one.
+//
+// The built in types are int, uint, float, alpha, alnum, uuid, and date.
+// Registering a type with the same name as an existing one replaces it for
+// patterns created afterwards. Types must be registered before the patterns
+// that use them are created.
+func RegisterParamType(name string, check func(value string) bool) {
+	if name == "" {
+		panic("param type name cannot be empty")
+	}
+	if check == nil {
+		panic("param type check function cannot be nil")
+	}
+	paramTypesMu.Lock()
+	paramTypes[name] = check
+	customParamTypes[name] = true
+	paramTypesMu.Unlock()
+}
+
+// lookupParamType returns the check function of a registered param type.
+func lookupParamType(name string) (func(value string) bool, bool) {
+	paramTypesMu.RLock()
+	check, ok := paramTypes[name]
+	paramTypesMu.RUnlock()
+	return check, ok
+}
+
+// parseDecimalFloat parses a decimal number, such as 9.99, -1, or 2.5e3,
+// as a float64. Unlike strconv.ParseFloat, it rejects NaN, infinities, hex
+// floats, and underscores.
+func parseDecimalFloat(value string) (float64, error) {
+	if !isDecimalNumber(value) {
+		return 0, &strconv.NumError{Func: "ParseFloat", Num: value, Err: strconv.ErrSyntax}
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// isDecimalNumber reports whether value is an optionally signed decimal
+// number, with an optional fraction and exponent.
+func isDecimalNumber(value string) bool {
+	i := 0
+	if i < len(value) && (value[i] == '+' || value[i] == '-') {
+		i += 1
+	}
+	digits := 0
+	for ; i < len(value) && isDigit(value[i]); i += 1 {
+		digits += 1
+	}
+	if i < len(value) && value[i] == '.' {
+		i += 1
+		for ; i < len(value) && isDigit(value[i]); i += 1 {
+			digits += 1
+		}
+	}
+	if digits == 0 {
+		return false
+	}
+	if i < len(value) && (value[i] == 'e' || value[i] == 'E') {
+		i += 1
+		if i < len(value) && (value[i] == '+' || value[i] == '-') {
+			i += 1
+		}
+		exponentDigits := 0
+		for ; i < len(value) && isDigit(value[i]); i += 1 {
+			exponentDigits += 1
+		}
+		if exponentDigits == 0 {
+			return false
+		}
+	}
+	return i == len(value)
+}
+
+// paramTypeSubsets maps each built in type to the built in types whose
+// values are all valid values of it.
+var paramTypeSubsets = map[string][]string{
+	"alnum": {"alpha"},
+	"float": {"int", "uint"},
+}
+
+// disjointParamTypes maps each built in type to the built in types which
+// share no values with it.
+var disjointParamTypes = map[string][]string{
+	"int":   {"alpha", "uuid", "date"},
+	"uint":  {"alpha", "uuid", "date"},
+	"float": {"alpha", "uuid", "date"},
+	"alpha": {"int", "uint", "float", "uuid", "date"},
+	"alnum": {"uuid", "date"},
+	"uuid":  {"int", "uint", "float", "alpha", "alnum", "date"},
+	"date":  {"int", "uint", "float", "alpha", "alnum", "uuid"},
+}
+
+// paramTypeCovers reports whether every value of type b is a value of type
+// a. Only built in types which have not been replaced are compared.
+func paramTypeCovers(a, b string) bool {
+	return a == b || isBuiltinParamType(a) && isBuiltinParamType(b) && slices.Contains(paramTypeSubsets[a], b)
+}
+
+// paramTypesAreDisjoint reports whether types a and b share no values. Only
+// built in types which have not been replaced can be shown to be disjoint.
+func paramTypesAreDisjoint(a, b string) bool {
+	return isBuiltinParamType(a) && isBuiltinParamType(b) && slices.Contains(disjointParamTypes[a], b)
+}
+
+// isBuiltinParamType reports whether a type is one of the built in types,
+// and has not been replaced with RegisterParamType.
+func isBuiltinParamType(name string) bool {
+	paramTypesMu.RLock()
+	defer paramTypesMu.RUnlock()
+	_, ok := paramTypes[name]
+	return ok && !customParamTypes[name]
+}
+
+func isAlpha(char byte) bool {
+	return char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z'
+}
+
+func isDigit(char byte) bool {
+	return char >= '0' && char <= '9'
+}
+
+func isHexDigit(char byte) bool {
+	return isDigit(char) || char >= 'a' && char <= 'f' || char >= 'A' && char <= 'F'
+}
@@ -0,0 +1,194 @@
+package navaros
+
+import (
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+)
+
+// PathPolicy determines how a router treats request paths that are not in
+// their canonical form. It is used with SetCleanPathPolicy and
+// SetTrailingSlashPolicy. The zero value selects the setting's default.
+type PathPolicy int
+
+const (
+	// LenientPath matches requests as if their paths were in canonical form.
+	LenientPath PathPolicy = iota + 1
+	// StrictPath matches request paths exactly as they are.
+	StrictPath
+	// RedirectPath redirects requests to the canonical form of their path.
+	// GET and HEAD requests are redirected with a 301 Moved Permanently, and
+	// requests with other methods with a 308 Permanent Redirect so that
+	// their method and body are kept.
+	RedirectPath
+)
+
+// cleanPath returns the escaped path p with repeated slashes collapsed, and
+// "." and ".." segments resolved. Percent-encoded unreserved characters are
+// decoded first, so encoded dot segments such as %2e%2e are resolved as
+// well. A trailing slash is kept. Paths which are already clean are returned
+// as is, without allocating.
+func cleanPath(p string) string {
+	if p == "" || p[0] != '/' {
+		return p
+	}
+	p = decodeUnreservedEscapes(p)
+	if isCleanPath(p) {
+		return p
+	}
+	cleaned := path.Clean(p)
+	if cleaned != "/" && p[len(p)-1] == '/' {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// decodeUnreservedEscapes decodes the percent-encoded unreserved characters
+// of an escaped path - letters, digits, "-", ".", "_", and "~". Encoding them
+// does not change the meaning of a path, so the result identifies the same
+// resource. Other escapes, such as %2F, are kept.
+func decodeUnreservedEscapes(p string) string {
+	i := strings.IndexByte(p, '%')
+	if i == -1 {
+		return p
+	}
+	var builder strings.Builder
+	start := 0
+	for ; i+2 < len(p); i += 1 {
+		if p[i] != '%' || !isHexDigit(p[i+1]) || !isHexDigit(p[i+2]) {
+			continue
+		}
+		char := unhex(p[i+1])<<4 | unhex(p[i+2])
+		if !isUnreserved(char) {
+			continue
+		}
+		if start == 0 {
+			builder.Grow(len(p))
+		}
+		builder.WriteString(p[start:i])
+		builder.WriteByte(char)
+		start = i + 3
+		i += 2
+	}
+	if start == 0 {
+		return p
+	}
+	builder.WriteString(p[start:])
+	return builder.String()
+}
+
+// isUnreserved reports whether a character is unreserved in URLs, and so
+// never needs to be percent-encoded.
+func isUnreserved(char byte) bool {
+	return isAlpha(char) || isDigit(char) || char == '-' || char == '.' || char == '_' || char == '~'
+}
+
+// unhex returns the value of a hex digit.
+func unhex(char byte) byte {
+	switch {
+	case isDigit(char):
+		return char - '0'
+	case char >= 'a' && char <= 'f':
+		return char - 'a' + 10
+	default:
+		return char - 'A' + 10
+	}
+}
+
+// isCleanPath reports whether a path has no repeated slashes, and no "." or
+// ".." segments.
+func isCleanPath(p string) bool {
+	for i := 0; i < len(p); i += 1 {
+		if p[i] != '/' {
+			continue
+		}
+		rest := p[i+1:]
+		if rest == "" {
+			return true
+		}
+		if rest[0] == '/' {
+			return false
+		}
+		if rest[0] == '.' {
+			if len(rest) == 1 || rest[1] == '/' {
+				return false
+			}
+			if rest[1] == '.' && (len(rest) == 2 || rest[2] == '/') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// toggleTrailingSlash adds a trailing slash to a path without one, or removes
+// it from a path with one.
+func toggleTrailingSlash(p string) string {
+	if strings.HasSuffix(p, "/") {
+		return strings.TrimSuffix(p, "/")
+	}
+	return p + "/"
+}
+
+// redirectToPath responds to a request with a permanent redirect to the same
+// URL with the given escaped path.
+func redirectToPath(ctx *Context, p string) {
+	if ctx.method == Get || ctx.method == Head {
+		ctx.Status = http.StatusMovedPermanently
+	} else {
+		ctx.Status = http.StatusPermanentRedirect
+	}
+	if rawQuery := ctx.request.URL.RawQuery; rawQuery != "" {
+		p += "?" + rawQuery
+	}
+	ctx.Body = &Redirect{To: p}
+}
+
+// pathMatchFlags returns the match flags for the router's path settings.
+func (r *Router) pathMatchFlags() matchFlags {
+	var flags matchFlags
+	if r.caseInsensitive {
+		flags |= matchFoldCase
+	}
+	if r.trailingSlashPolicy == StrictPath || r.trailingSlashPolicy == RedirectPath {
+		flags |= matchStrictTrailingSlash
+	}
+	return flags
+}
+
+// normalizePath applies the router's clean path policy to the context's
+// path. True is returned if the request was answered with a redirect to the
+// clean path, in which case it should not be routed.
+func (r *Router) normalizePath(ctx *Context) bool {
+	if r.cleanPathPolicy != LenientPath && r.cleanPathPolicy != RedirectPath {
+		return false
+	}
+	cleanedPath := cleanPath(ctx.path)
+	if cleanedPath == ctx.path {
+		return false
+	}
+	if r.cleanPathPolicy == RedirectPath {
+		redirectToPath(ctx, cleanedPath)
+		return true
+	}
+	ctx.path = cleanedPath
+	return false
+}
+
+// redirectTrailingSlash redirects a request no route handled to the same
+// path with its trailing slash added or removed, if the router's trailing
+// slash policy is RedirectPath and a route for the request's method matches
+// that path. True is returned if the request was redirected.
+func (r *Router) redirectTrailingSlash(ctx *Context) bool {
+	if r.trailingSlashPolicy != RedirectPath || ctx.path == "/" {
+		return false
+	}
+	toggledPath := toggleTrailingSlash(ctx.path)
+	methods := r.appendMatchingMethods(nil, ctx, toggledPath)
+	if !slices.Contains(methods, ctx.method) && (ctx.method != Head || r.disableAutoHead || !slices.Contains(methods, Get)) {
+		return false
+	}
+	redirectToPath(ctx, toggledPath)
+	return true
+}
@@ -0,0 +1,177 @@
+package navaros_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RobertWHurst/navaros"
+)
+
+func TestRouterCleanPathPolicy(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/admin", func(ctx *navaros.Context) { ctx.Body = "admin" })
+	router.Get("/users/", func(ctx *navaros.Context) { ctx.Body = "users" })
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/users/../admin", nil))
+	if res.Code != http.StatusNotFound {
+		t.Errorf("expected paths to be matched as is by default, got %d", res.Code)
+	}
+
+	router.SetCleanPathPolicy(navaros.LenientPath)
+	for _, path := range []string{"//users/../admin", "/./admin", "/users//..//admin"} {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest("GET", path, nil))
+		if res.Body.String() != "admin" {
+			t.Errorf("expected %s to match /admin, got %d %s", path, res.Code, res.Body.String())
+		}
+	}
+
+	router.SetCleanPathPolicy(navaros.RedirectPath)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "//users/./?page=2", nil))
+	if res.Code != http.StatusMovedPermanently {
+		t.Errorf("expected 301, got %d", res.Code)
+	}
+	if res.Header().Get("Location") != "/users/?page=2" {
+		t.Errorf("expected /users/?page=2, got %s", res.Header().Get("Location"))
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("POST", "/a/../admin", nil))
+	if res.Code != http.StatusPermanentRedirect {
+		t.Errorf("expected 308, got %d", res.Code)
+	}
+	if res.Header().Get("Location") != "/admin" {
+		t.Errorf("expected /admin, got %s", res.Header().Get("Location"))
+	}
+}
+
+func TestRouterTrailingSlashPolicy(t *testing.T) {
+	newRouter := func(policy navaros.PathPolicy) *navaros.Router {
+		router := navaros.NewRouter()
+		router.SetTrailingSlashPolicy(policy)
+		router.Get("/users", func(ctx *navaros.Context) { ctx.Body = "users" })
+		router.Post("/posts/", func(ctx *navaros.Context) { ctx.Body = "posts" })
+		return router
+	}
+
+	cases := []struct {
+		policy   navaros.PathPolicy
+		method   string
+		path     string
+		status   int
+		location string
+	}{
+		{navaros.LenientPath, "GET", "/users/", http.StatusOK, ""},
+		{navaros.LenientPath, "POST", "/posts", http.StatusOK, ""},
+		{navaros.StrictPath, "GET", "/users", http.StatusOK, ""},
+		{navaros.StrictPath, "GET", "/users/", http.StatusNotFound, ""},
+		{navaros.StrictPath, "POST", "/posts", http.StatusNotFound, ""},
+		{navaros.StrictPath, "POST", "/posts/", http.StatusOK, ""},
+		{navaros.RedirectPath, "GET", "/users/", http.StatusMovedPermanently, "/users"},
+		{navaros.RedirectPath, "HEAD", "/users/", http.StatusMovedPermanently, "/users"},
+		{navaros.RedirectPath, "POST", "/posts", http.StatusPermanentRedirect, "/posts/"},
+		{navaros.RedirectPath, "POST", "/users/", http.StatusNotFound, ""},
+		{navaros.RedirectPath, "GET", "/missing/", http.StatusNotFound, ""},
+	}
+	for _, c := range cases {
+		res := httptest.NewRecorder()
+		newRouter(c.policy).ServeHTTP(res, httptest.NewRequest(c.method, c.path, nil))
+		if res.Code != c.status {
+			t.Errorf("%s %s with policy %d: expected %d, got %d", c.method, c.path, c.policy, c.status, res.Code)
+		}
+		if res.Header().Get("Location") != c.location {
+			t.Errorf("%s %s with policy %d: expected location %q, got %q", c.method, c.path, c.policy, c.location, res.Header().Get("Location"))
+		}
+	}
+}
+
+func TestRouterTrailingSlashPolicyWithSubRouter(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.Get("/", func(ctx *navaros.Context) { ctx.Body = "index" })
+	subRouter.Get("/items", func(ctx *navaros.Context) { ctx.Body = "items" })
+
+	router := navaros.NewRouter()
+	router.SetTrailingSlashPolicy(navaros.RedirectPath)
+	router.Use("/api", subRouter)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/api/", nil))
+	if res.Body.String() != "index" {
+		t.Errorf("expected index, got %d %s", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/api/items/", nil))
+	if res.Code != http.StatusMovedPermanently || res.Header().Get("Location") != "/api/items" {
+		t.Errorf("expected a redirect to /api/items, got %d %s", res.Code, res.Header().Get("Location"))
+	}
+}
+
+func TestRouterCaseInsensitive(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.Get("/Posts/:id", func(ctx *navaros.Context) {
+		ctx.Body = ctx.Params().Get("id")
+	})
+
+	router := navaros.NewRouter()
+	router.Get("/users/:name", func(ctx *navaros.Context) {
+		ctx.Body = ctx.Params().Get("name")
+	})
+	router.Use("/blog", subRouter)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/Users/Alice", nil))
+	if res.Code != http.StatusNotFound {
+		t.Errorf("expected matching to be case sensitive by default, got %d", res.Code)
+	}
+
+	router.SetCaseInsensitive(true)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/USERS/Alice", nil))
+	if res.Body.String() != "Alice" {
+		t.Errorf("expected Alice, got %d %s", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/Blog/posts/AbC", nil))
+	if res.Body.String() != "AbC" {
+		t.Errorf("expected AbC, got %d %s", res.Code, res.Body.String())
+	}
+}
+
+func TestRouterCleanPathPolicyEncodedDotSegments(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/admin", func(ctx *navaros.Context) { ctx.Body = "admin " + ctx.Path() })
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/x/%2e%2e/admin", nil))
+	if res.Code != http.StatusNotFound {
+		t.Errorf("expected paths to be matched as is by default, got %d", res.Code)
+	}
+
+	router.SetCleanPathPolicy(navaros.LenientPath)
+	for _, path := range []string{"/x/%2e%2e/admin", "/x/%2E./admin", "/%2e/admin", "/%61dmin"} {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest("GET", path, nil))
+		if res.Body.String() != "admin /admin" {
+			t.Errorf("expected %s to match /admin with the cleaned path, got %d %s", path, res.Code, res.Body.String())
+		}
+	}
+
+	router.SetCleanPathPolicy(navaros.RedirectPath)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/x/%2e%2e/admin", nil))
+	if res.Code != http.StatusMovedPermanently || res.Header().Get("Location") != "/admin" {
+		t.Errorf("expected a redirect to /admin, got %d %s", res.Code, res.Header().Get("Location"))
+	}
+
+	// Escaped slashes are not unreserved, so they stay part of a segment.
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/x%2F..%2Fadmin", nil))
+	if res.Code != http.StatusNotFound {
+		t.Errorf("expected escaped slashes to be kept, got %d %s", res.Code, res.Header().Get("Location"))
+	}
+}
@@ -2,6 +2,9 @@ package navaros
 
 import (
 	"errors"
+	"net/url"
+	"regexp/syntax"
+	"strings"
 
 	"github.com/grafana/regexp"
 )
@@ -9,55 +12,208 @@ import (
 // Pattern is used to compare and match request paths to route patterns.
 // Patterns are used by the router to determine which handlers to execute for
 // a given request.
+//
+// Patterns are matched one path segment at a time. Regular expressions are
+// only used for segments with custom sub-patterns; static, dynamic, and
+// wildcard segments are compared directly. Patterns with sub-patterns that can
+// match a slash, such as "(.*)", are matched against the whole escaped path
+// with a single regular expression instead, so their values can span
+// segments.
 type Pattern struct {
 	str    string
 	chunks []chunk
-	regExp *regexp.Regexp
+	isHost bool
+
+	// paramKeys holds the names of the pattern's params, by capture index,
+	// and paramIndexes the capture index of each name. Params with the same
+	// name share a capture index.
+	paramKeys    []string
+	paramIndexes map[string]int
+
+	// trailingSlash is true for path patterns written with a trailing slash.
+	// It only affects matching when trailing slashes are strict.
+	trailingSlash bool
+
+	// spanRegExp matches whole paths for patterns with sub-patterns that can
+	// span segments, and spanFoldRegExp does the same with static text
+	// compared case-insensitively. chunkGroups holds the capture group of
+	// each chunk's match within them. They are nil for other patterns.
+	spanRegExp     *regexp.Regexp
+	spanFoldRegExp *regexp.Regexp
+	chunkGroups    []int
 }
 
+// matchFlags alter how a pattern matches a path. The router sets them on the
+// context according to its path settings.
+type matchFlags uint8
+
+const (
+	// matchFoldCase compares static segments case-insensitively.
+	matchFoldCase matchFlags = 1 << iota
+	// matchStrictTrailingSlash only allows a trailing slash on the path if
+	// the pattern has one, and requires it if it does.
+	matchStrictTrailingSlash
+)
+
 // NewPattern creates a new pattern from a string. The string should be a
 // valid route pattern. If the string is not a valid route pattern, an error
 // is returned.
 func NewPattern(patternStr string) (*Pattern, error) {
-	chunks, err := parsePatternChunks(patternStr)
+	return newPattern(patternStr, patternStr, false)
+}
+
+// NewHostPattern creates a new pattern for matching request hosts rather than
+// paths. Host patterns use the same syntax as route patterns, but their
+// segments are separated by dots and there is no leading separator. For
+// example, ":tenant.api.example.com" captures the first label of the host as
+// the tenant parameter, and "**.example.com" matches example.com and any of
+// its subdomains. Static labels are compared case-insensitively.
+func NewHostPattern(patternStr string) (*Pattern, error) {
+	if patternStr == "" {
+		return nil, errors.New("host pattern cannot be empty")
+	}
+	if strings.ContainsRune(patternStr, '/') {
+		return nil, errors.New("host pattern cannot contain slashes")
+	}
+	return newPattern(patternStr, hostPatternToPathPattern(patternStr), true)
+}
+
+// hostPatternToPathPattern rewrites a host pattern as a path pattern so it can
+// be parsed by the same parser. Dots outside of custom sub-patterns become
+// slashes.
+func hostPatternToPathPattern(patternStr string) string {
+	var builder strings.Builder
+	builder.Grow(len(patternStr) + 1)
+	builder.WriteByte('/')
+
+	depth := 0
+	escaped := false
+	for i := 0; i < len(patternStr); i += 1 {
+		char := patternStr[i]
+		switch {
+		case escaped:
+			escaped = false
+		case char == '\\':
+			escaped = true
+		case char == '(':
+			depth += 1
+		case char == ')':
+			depth -= 1
+		case char == '.' && depth == 0:
+			char = '/'
+		}
+		builder.WriteByte(char)
+	}
+
+	return builder.String()
+}
+
+func newPattern(patternStr, parseStr string, isHost bool) (*Pattern, error) {
+	chunks, err := parsePatternChunks(parseStr)
 	if err != nil {
 		return nil, err
 	}
 
-	patternRegExp, err := regExpFromChunks(chunks)
+	// The full regular expression is no longer used for matching, but
+	// compiling it validates the pattern as a whole - for example catching
+	// invalid parameter names.
+	if _, err := regExpFromChunks(chunks); err != nil {
+		return nil, err
+	}
+
+	chunks, err = compileChunks(chunks)
 	if err != nil {
 		return nil, err
 	}
 
 	pattern := &Pattern{
-		str:    patternStr,
-		chunks: chunks,
-		regExp: patternRegExp,
+		str:           patternStr,
+		chunks:        chunks,
+		isHost:        isHost,
+		trailingSlash: !isHost && len(patternStr) > 1 && strings.HasSuffix(patternStr, "/"),
+	}
+	pattern.indexParams()
+
+	if !isHost && chunksSpanSegments(chunks) {
+		pattern.spanRegExp, pattern.chunkGroups, err = spanningRegExpFromChunks(chunks, false)
+		if err != nil {
+			return nil, err
+		}
+		pattern.spanFoldRegExp, _, err = spanningRegExpFromChunks(chunks, true)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return pattern, nil
 }
 
+// indexParams assigns each of the pattern's params a capture index, in the
+// order they appear in the pattern.
+func (p *Pattern) indexParams() {
+	p.paramIndexes = map[string]int{}
+	indexParam := func(key string) int {
+		index, ok := p.paramIndexes[key]
+		if !ok {
+			index = len(p.paramKeys)
+			p.paramIndexes[key] = index
+			p.paramKeys = append(p.paramKeys, key)
+		}
+		return index
+	}
+	for i := range p.chunks {
+		currentChunk := &p.chunks[i]
+		switch {
+		case currentChunk.kind == mixed:
+			for j := range currentChunk.parts {
+				if currentChunk.parts[j].kind == dynamic {
+					currentChunk.parts[j].paramIndex = indexParam(currentChunk.parts[j].key)
+				}
+			}
+		case currentChunk.kind == dynamic || (currentChunk.kind == wildcard && currentChunk.key != ""):
+			currentChunk.paramIndex = indexParam(currentChunk.key)
+		}
+	}
+}
+
 // Match compares a path to the pattern and returns a map of named parameters
 // extracted from the path as per the pattern. If the path matches the pattern,
 // the second return value will be true. If the path does not match the pattern,
-// the second return value will be false.
+// the second return value will be false. The path is expected to be escaped,
+// as returned by url.URL's EscapedPath, and parameter values are decoded.
 func (p *Pattern) Match(path string) (RequestParams, bool) {
-	matches := p.regExp.FindStringSubmatch(path)
-	if len(matches) == 0 {
+	var captureBuf [maxStackCaptures][2]int
+	captures := p.captureSlice(captureBuf[:])
+	if !p.match(path, captures, 0) {
 		return nil, false
 	}
 
-	keys := p.regExp.SubexpNames()
+	params := make(RequestParams, len(p.paramKeys))
+	p.fillParams(path, captures, params, p.isHost)
 
-	params := make(RequestParams, len(keys))
-	for i := 1; i < len(keys); i += 1 {
-		if keys[i] != "" {
-			params[keys[i]] = matches[i]
-		}
+	return params, true
+}
+
+// MatchWithWildcards is Match, but also returns the values matched by the
+// pattern's unnamed wildcard segments, in order. Wildcards with a repeating
+// modifier, such as "**", capture every segment they match joined by slashes,
+// and optional wildcards that match nothing capture an empty string. Named
+// wildcards, such as "*path", are returned with the params instead. The
+// wildcards are percent-decoded, so an encoded slash within a segment can't
+// be told apart from one between segments; use the escaped path with
+// Context.RawWildcards if that matters. The results can be passed back to
+// Path to rebuild the path.
+func (p *Pattern) MatchWithWildcards(path string) (RequestParams, []string, bool) {
+	var captureBuf [maxStackCaptures][2]int
+	captures := p.captureSlice(captureBuf[:])
+	if !p.match(path, captures, 0) {
+		return nil, nil, false
 	}
 
-	return params, true
+	params := make(RequestParams, len(p.paramKeys))
+	p.fillParams(path, captures, params, p.isHost)
+
+	return params, p.appendWildcards(path, captures, nil, true), true
 }
 
 // MatchInto takes a path and a request params map and extracts the named
@@ -65,33 +221,70 @@ func (p *Pattern) Match(path string) (RequestParams, bool) {
 // will also be returned. If the path does not match the pattern, false will be
 // returned, and no changes will be made to the map.
 func (p *Pattern) MatchInto(path string, params *RequestParams) bool {
-	matchIndices := p.regExp.FindStringSubmatchIndex(path)
-	if len(matchIndices) == 0 {
-		return false
+	if *params == nil {
+		*params = make(RequestParams, len(p.paramKeys))
 	}
+	return p.matchInto(path, *params, nil, 0)
+}
 
-	keys := p.regExp.SubexpNames()
+// matchInto is MatchInto with match flags, for any params store. If
+// wildcards is not nil, the values matched by the pattern's unnamed wildcards
+// replace its contents. They are left escaped, as they are in path.
+func (p *Pattern) matchInto(path string, params paramStore, wildcards *[]string, flags matchFlags) bool {
+	var captureBuf [maxStackCaptures][2]int
+	captures := p.captureSlice(captureBuf[:])
+	if !p.match(path, captures, flags) {
+		return false
+	}
 
-	if *params == nil {
-		*params = make(map[string]string, len(keys))
+	params.resetParams(p)
+	p.fillParams(path, captures, params, p.foldCase(flags))
+	if wildcards != nil {
+		*wildcards = p.appendWildcards(path, captures, (*wildcards)[:0], false)
 	}
 
-	for key := range *params {
-		delete(*params, key)
+	return true
+}
+
+// addMatchedParams matches str against the pattern, and if it matches adds the
+// captured parameters to params without removing any already present. Host
+// patterns use this so their parameters can sit alongside those of the path.
+func (p *Pattern) addMatchedParams(str string, params paramStore) bool {
+	var captureBuf [maxStackCaptures][2]int
+	captures := p.captureSlice(captureBuf[:])
+	if !p.match(str, captures, 0) {
+		return false
 	}
-	for i := 1; i < len(keys); i += 1 {
-		if keys[i] != "" {
-			startIdx := matchIndices[i*2]
-			endIdx := matchIndices[i*2+1]
-			if startIdx >= 0 && endIdx >= 0 {
-				(*params)[keys[i]] = path[startIdx:endIdx]
-			} else {
-				(*params)[keys[i]] = ""
-			}
-		}
+	p.fillParams(str, captures, params, p.isHost)
+	return true
+}
+
+// matchesPath reports whether path matches the pattern without extracting
+// any parameters.
+func (p *Pattern) matchesPath(path string, flags matchFlags) bool {
+	var captureBuf [maxStackCaptures][2]int
+	return p.match(path, p.captureSlice(captureBuf[:]), flags)
+}
+
+// mountSubPath returns the part of path matched by the pattern's trailing
+// "**" chunk. Routers mounted with Use match their own routes against this
+// sub path. False is returned if the pattern does not end with "**", or does
+// not match the path.
+func (p *Pattern) mountSubPath(path string, flags matchFlags) (string, bool) {
+	if !p.endsWithMountWildcard() {
+		return "", false
 	}
+	lastIndex := len(p.chunks) - 1
 
-	return true
+	var captureBuf [maxStackCaptures][2]int
+	captures := p.captureSlice(captureBuf[:])
+	if !p.match(path, captures, flags) {
+		return "", false
+	}
+	if captures[lastIndex][0] < 0 {
+		return "/", true
+	}
+	return path[captures[lastIndex][0]-1:], true
 }
 
 // String returns the string representation of the pattern.
@@ -104,16 +297,39 @@ func (p *Pattern) String() string {
 // returned. Optional segments are only included if their parameters are provided.
 // Wildcard segments are replaced with values from the wildcards slice in order.
 // If there are more wildcard segments than values in the slice, an error is returned.
+// Parameter and wildcard values are percent-encoded, so a slash within the
+// value of a single segment parameter becomes %2F, while values of repeating
+// segments keep their slashes. For host patterns the result is a host, with
+// its labels joined by dots, and values are not encoded.
 func (p *Pattern) Path(params RequestParams, wildcards []string) (string, error) {
+	return p.buildPath(p.chunks, params, wildcards, !p.isHost)
+}
+
+// buildPath creates a path from the given chunks of the pattern, as described
+// for Path. If escape is true, parameter and wildcard values are
+// percent-encoded so they can be used within a URL. Slashes are escaped too,
+// except in the values of repeating chunks, where they separate segments.
+func (p *Pattern) buildPath(chunks []chunk, params RequestParams, wildcards []string, escape bool) (string, error) {
 	path := ""
+	separator := string(separatorFor(p.isHost))
 	wildcardIndex := 0
 
 	// Build the path from chunks
-	for _, currentChunk := range p.chunks {
-		switch currentChunk.kind {
+	for _, currentChunk := range chunks {
+		// Named wildcards take their values from params, like dynamic chunks.
+		kind := currentChunk.kind
+		if kind == wildcard && currentChunk.key != "" {
+			kind = dynamic
+		}
+
+		switch kind {
 		case static:
 			// Static segments are always included
-			path += "/" + currentChunk.pattern
+			if escape && currentChunk.literal != "" {
+				path += separator + escapePathValue(currentChunk.literal, false)
+			} else {
+				path += separator + currentChunk.pattern
+			}
 		case dynamic:
 			value, exists := params[currentChunk.key]
 
@@ -127,17 +343,68 @@ func (p *Pattern) Path(params RequestParams, wildcards []string) (string, error)
 			}
 
 			// Add the parameter value
-			path += "/" + value
+			if escape {
+				value = escapePathValue(value, currentChunk.modifier >= oneOrMore)
+			}
+			path += separator + value
+		case mixed:
+			segment := ""
+			missingKey := ""
+			providedCount := 0
+			for _, part := range currentChunk.parts {
+				if part.kind == static {
+					if escape {
+						segment += escapePathValue(part.literal, false)
+					} else {
+						segment += part.pattern
+					}
+					continue
+				}
+				value, exists := params[part.key]
+				if !exists {
+					if missingKey == "" {
+						missingKey = part.key
+					}
+					continue
+				}
+				providedCount += 1
+				if escape {
+					value = escapePathValue(value, false)
+				}
+				segment += value
+			}
+
+			// Optional segments are skipped if none of their parameters are
+			// provided, but otherwise need all of them.
+			if missingKey != "" {
+				if currentChunk.modifier == optional && providedCount == 0 {
+					continue
+				}
+				return "", errors.New("missing required parameter: " + missingKey)
+			}
+			path += separator + segment
 		case wildcard:
 			// Use next wildcard value from slice
 			if wildcardIndex >= len(wildcards) {
 				return "", errors.New("not enough wildcard values provided")
 			}
-			path += "/" + wildcards[wildcardIndex]
+			value := wildcards[wildcardIndex]
 			wildcardIndex++
+
+			// Optional wildcards which matched nothing are skipped
+			if value == "" && (currentChunk.modifier == optional || currentChunk.modifier == zeroOrMore) {
+				continue
+			}
+			if escape {
+				value = escapePathValue(value, currentChunk.modifier >= oneOrMore)
+			}
+			path += separator + value
 		}
 	}
 
+	if p.isHost {
+		return strings.TrimPrefix(path, separator), nil
+	}
 	if path == "" {
 		path = "/"
 	}
@@ -145,6 +412,41 @@ func (p *Pattern) Path(params RequestParams, wildcards []string) (string, error)
 	return path, nil
 }
 
+// mountPrefixPath builds the path a router mounted with this pattern is
+// mounted at, by building every chunk but the trailing "**" wildcard. The
+// result is empty for routers mounted at the root, or with patterns that do
+// not end in "**", as such routers match against the full request path.
+func (p *Pattern) mountPrefixPath(params RequestParams) (string, error) {
+	lastIndex := len(p.chunks) - 1
+	if lastIndex <= 0 || !p.endsWithMountWildcard() {
+		return "", nil
+	}
+	return p.buildPath(p.chunks[:lastIndex], params, nil, true)
+}
+
+// endsWithMountWildcard reports whether the pattern ends with a "**" chunk
+// without a sub-pattern, as the patterns routers are mounted with do.
+func (p *Pattern) endsWithMountWildcard() bool {
+	if len(p.chunks) == 0 {
+		return false
+	}
+	lastChunk := p.chunks[len(p.chunks)-1]
+	return lastChunk.kind == wildcard && lastChunk.modifier == zeroOrMore && lastChunk.regExp == nil
+}
+
+// escapePathValue percent-encodes value for use in a path. If keepSlashes is
+// true, each slash separated segment of value is encoded on its own.
+func escapePathValue(value string, keepSlashes bool) string {
+	if !keepSlashes || !strings.ContainsRune(value, '/') {
+		return url.PathEscape(value)
+	}
+	segments := strings.Split(value, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
 type chunkKind int
 
 const (
@@ -152,6 +454,9 @@ const (
 	static
 	dynamic
 	wildcard
+	// mixed chunks match a single segment made of several static and dynamic
+	// parts, such as ":name.:ext".
+	mixed
 )
 
 type chunkModifier int
@@ -164,12 +469,47 @@ const (
 )
 
 type chunk = struct {
-	kind     chunkKind
-	modifier chunkModifier
-	key      string
-	pattern  string
+	kind      chunkKind
+	modifier  chunkModifier
+	key       string
+	pattern   string
+	paramType string
+
+	// Set by compileChunks. literal holds the text a static chunk must equal,
+	// and regExp is only set for chunks that need a regular expression to
+	// match a segment. checkParamType is set for dynamic chunks constrained
+	// to a param type.
+	literal        string
+	regExp         *regexp.Regexp
+	checkParamType func(value string) bool
+
+	// parts holds the parts of a mixed chunk, in order.
+	parts []chunkPart
+
+	// paramIndex is the capture index of a dynamic chunk or named wildcard's
+	// param. It's set once the pattern is compiled.
+	paramIndex int
 }
 
+// chunkPart is a static or dynamic part of a mixed chunk. Its fields mean the
+// same as those of a chunk.
+type chunkPart struct {
+	kind      chunkKind
+	key       string
+	pattern   string
+	paramType string
+
+	literal        string
+	regExp         *regexp.Regexp
+	checkParamType func(value string) bool
+
+	paramIndex int
+}
+
+// maxStackParts is the number of parts a mixed chunk can have before the
+// offsets of their matches are allocated on the heap rather than the stack.
+const maxStackParts = 8
+
 func parsePatternChunks(patternStr string) ([]chunk, error) {
 	patternRunes := []rune(patternStr)
 	patternRunesLen := len(patternRunes)
@@ -183,6 +523,7 @@ func parsePatternChunks(patternStr string) ([]chunk, error) {
 
 		if currentRune == '/' {
 			if currentChunk != nil {
+				finishChunk(currentChunk)
 				chunks = append(chunks, *currentChunk)
 			}
 			currentChunk = &chunk{}
@@ -208,6 +549,32 @@ func parsePatternChunks(patternStr string) ([]chunk, error) {
 			continue
 		}
 
+		if currentRune == '<' && currentChunk.kind == dynamic {
+			if currentChunk.key == "" {
+				return nil, errors.New("dynamic chunks must have a name")
+			}
+			if currentChunk.paramType != "" || currentChunk.pattern != "" {
+				return nil, errors.New("param types must directly follow the param name")
+			}
+
+			closeIndex := -1
+			for j := i + 1; j < patternRunesLen; j += 1 {
+				if patternRunes[j] == '>' {
+					closeIndex = j
+					break
+				}
+			}
+			if closeIndex == -1 {
+				return nil, errors.New("param type for `" + currentChunk.key + "` is missing a closing >")
+			}
+			currentChunk.paramType = string(patternRunes[i+1 : closeIndex])
+			if currentChunk.paramType == "" {
+				return nil, errors.New("param type for `" + currentChunk.key + "` cannot be empty")
+			}
+			i = closeIndex
+			continue
+		}
+
 		if currentRune == '(' {
 			if currentChunk.kind == dynamic && currentChunk.key == "" {
 				return nil, errors.New("dynamic chunks must have a name")
@@ -231,31 +598,97 @@ func parsePatternChunks(patternStr string) ([]chunk, error) {
 			switch currentRune {
 			case '?':
 				currentChunk.modifier = optional
+				continue
 			case '+':
 				currentChunk.modifier = oneOrMore
+				continue
 			case '*':
 				currentChunk.modifier = zeroOrMore
-			}
-			if currentChunk.modifier != single {
 				continue
 			}
 		}
 
 		switch currentChunk.kind {
 		case dynamic:
-			currentChunk.key += string(currentRune)
+			// A param's name ends at the first character that cannot be part
+			// of it, or after its type or sub-pattern, and static text
+			// follows within the same segment. Regular expression syntax
+			// cannot begin static text, so it is left in the name, where it
+			// is reported as an invalid name.
+			if (isParamNameRune(currentRune) || strings.ContainsRune(regExpSyntax, currentRune)) &&
+				currentChunk.paramType == "" && currentChunk.pattern == "" {
+				currentChunk.key += string(currentRune)
+				continue
+			}
+			if currentChunk.key == "" {
+				return nil, errors.New("dynamic chunks must have a name")
+			}
+			if currentRune == ':' {
+				return nil, errors.New("params within a segment must be separated by static text")
+			}
+			addChunkPart(currentChunk)
+			currentChunk.kind = static
+			currentChunk.pattern = string(currentRune)
 		case static:
+			// A colon followed by a name starts a param within the segment,
+			// unless it is escaped.
+			if currentRune == ':' && !isLastRuneInChunk && isParamNameRune(patternRunes[i+1]) &&
+				!strings.HasSuffix(currentChunk.pattern, "\\") {
+				addChunkPart(currentChunk)
+				currentChunk.kind = dynamic
+				continue
+			}
 			currentChunk.pattern += string(currentRune)
 		case wildcard:
+			// A second star directly after the first makes the wildcard
+			// match any number of segments. Either may be followed by a
+			// name, which makes the wildcard capture a param.
+			if currentRune == '*' && i > 0 && patternRunes[i-1] == '*' && currentChunk.key == "" && currentChunk.modifier == single {
+				currentChunk.modifier = zeroOrMore
+				continue
+			}
+			if isParamNameRune(currentRune) && currentChunk.pattern == "" {
+				currentChunk.key += string(currentRune)
+			}
 		}
 	}
 	if currentChunk != nil {
+		finishChunk(currentChunk)
 		chunks = append(chunks, *currentChunk)
 	}
 
 	return chunks, nil
 }
 
+// addChunkPart moves the part of a segment parsed so far into the chunk's
+// parts, so the parser can continue with the next part.
+func addChunkPart(currentChunk *chunk) {
+	currentChunk.parts = append(currentChunk.parts, chunkPart{
+		kind:      currentChunk.kind,
+		key:       currentChunk.key,
+		pattern:   currentChunk.pattern,
+		paramType: currentChunk.paramType,
+	})
+	currentChunk.key = ""
+	currentChunk.pattern = ""
+	currentChunk.paramType = ""
+}
+
+// finishChunk completes a parsed chunk. Chunks with more than one part become
+// mixed chunks.
+func finishChunk(currentChunk *chunk) {
+	if len(currentChunk.parts) == 0 {
+		return
+	}
+	addChunkPart(currentChunk)
+	currentChunk.kind = mixed
+}
+
+// isParamNameRune reports whether a rune can be part of a param name.
+func isParamNameRune(char rune) bool {
+	return char < 128 && (char == '_' || isAlpha(byte(char)) || isDigit(byte(char)))
+}
+
 // regExpFromChunks converts parsed pattern chunks to a regular expression.
 func regExpFromChunks(chunks []chunk) (*regexp.Regexp, error) {
 	regExpStr := "^"
@@ -265,7 +698,12 @@ func regExpFromChunks(chunks []chunk) (*regexp.Regexp, error) {
 			currentChunk.pattern = "[^\\/]+"
 		}
 
-		switch currentChunk.kind {
+		kind := currentChunk.kind
+		if kind == wildcard && currentChunk.key != "" {
+			kind = dynamic
+		}
+
+		switch kind {
 		case static, wildcard:
 			switch currentChunk.modifier {
 			case single:
@@ -277,6 +715,23 @@ func regExpFromChunks(chunks []chunk) (*regexp.Regexp, error) {
 			case zeroOrMore:
 				regExpStr += "(?:\\/" + currentChunk.pattern + "(?:\\/" + currentChunk.pattern + ")*)?"
 			}
+		case mixed:
+			segment := ""
+			for _, part := range currentChunk.parts {
+				if part.kind == static {
+					segment += part.pattern
+					continue
+				}
+				if part.pattern == "" {
+					part.pattern = "[^\\/]+"
+				}
+				segment += "(?P<" + part.key + ">" + part.pattern + ")"
+			}
+			if currentChunk.modifier == optional {
+				regExpStr += "(?:\\/" + segment + ")?"
+			} else {
+				regExpStr += "\\/" + segment
+			}
 		case dynamic:
 			switch currentChunk.modifier {
 			case single:
@@ -300,3 +755,643 @@ func regExpFromChunks(chunks []chunk) (*regexp.Regexp, error) {
 
 	return regExp, nil
 }
+
+// chunksSpanSegments reports whether any of the chunks has a custom
+// sub-pattern which can match a slash, and so may match more than one
+// segment.
+func chunksSpanSegments(chunks []chunk) bool {
+	for _, currentChunk := range chunks {
+		if currentChunk.kind == mixed {
+			for _, part := range currentChunk.parts {
+				if part.kind != static && regExpCanMatchSlash(part.pattern) {
+					return true
+				}
+			}
+			continue
+		}
+		if currentChunk.regExp != nil && regExpCanMatchSlash(currentChunk.pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// regExpCanMatchSlash reports whether a regular expression contains anything
+// which can match a slash, such as "." or a character class including it.
+func regExpCanMatchSlash(pattern string) bool {
+	if pattern == "" {
+		return false
+	}
+	regExp, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return false
+	}
+	return syntaxCanMatchRune(regExp, '/')
+}
+
+// syntaxCanMatchRune reports whether a parsed regular expression, or any of
+// its sub-expressions, can match the given rune.
+func syntaxCanMatchRune(regExp *syntax.Regexp, char rune) bool {
+	switch regExp.Op {
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return true
+	case syntax.OpLiteral:
+		for _, literalRune := range regExp.Rune {
+			if literalRune == char {
+				return true
+			}
+		}
+	case syntax.OpCharClass:
+		for i := 0; i+1 < len(regExp.Rune); i += 2 {
+			if regExp.Rune[i] <= char && char <= regExp.Rune[i+1] {
+				return true
+			}
+		}
+	}
+	for _, subRegExp := range regExp.Sub {
+		if syntaxCanMatchRune(subRegExp, char) {
+			return true
+		}
+	}
+	return false
+}
+
+// spanningRegExpFromChunks builds the whole path regular expression for a
+// pattern with sub-patterns that span segments. Each chunk's match, without
+// its leading slash, is captured by a group of its own, and the index of
+// each chunk's group is returned alongside the regular expression. If
+// foldCase is true, static text is compared case-insensitively.
+func spanningRegExpFromChunks(chunks []chunk, foldCase bool) (*regexp.Regexp, []int, error) {
+	builder := strings.Builder{}
+	builder.WriteString("^")
+	chunkGroups := make([]int, len(chunks))
+	groupCount := 0
+	for i := range chunks {
+		currentChunk := &chunks[i]
+		segment, segmentGroupCount := spanningSegmentRegExp(currentChunk, foldCase)
+
+		groupCount += 1
+		chunkGroups[i] = groupCount
+		switch currentChunk.modifier {
+		case single:
+			builder.WriteString("/(" + segment + ")")
+			groupCount += segmentGroupCount
+		case optional:
+			builder.WriteString("(?:/(" + segment + "))?")
+			groupCount += segmentGroupCount
+		case oneOrMore:
+			builder.WriteString("/(" + segment + "(?:/" + segment + ")*)")
+			groupCount += 2 * segmentGroupCount
+		case zeroOrMore:
+			builder.WriteString("(?:/(" + segment + "(?:/" + segment + ")*))?")
+			groupCount += 2 * segmentGroupCount
+		}
+	}
+	builder.WriteString("$")
+
+	regExp, err := regexp.Compile(builder.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	return regExp, chunkGroups, nil
+}
+
+// spanningSegmentRegExp returns the regular expression for a single
+// repetition of a chunk within a whole path regular expression, along with
+// the number of capture groups the chunk's sub-patterns contain. Params
+// without sub-patterns match a single segment.
+func spanningSegmentRegExp(currentChunk *chunk, foldCase bool) (string, int) {
+	switch {
+	case currentChunk.kind == mixed:
+		segment := ""
+		groupCount := 0
+		for _, part := range currentChunk.parts {
+			switch {
+			case part.kind == static:
+				segment += literalRegExp(part.literal, foldCase)
+			case part.regExp != nil:
+				segment += "(?:" + part.pattern + ")"
+				groupCount += part.regExp.NumSubexp()
+			default:
+				segment += "[^/]+"
+			}
+		}
+		return segment, groupCount
+	case currentChunk.regExp != nil:
+		return "(?:" + currentChunk.pattern + ")", currentChunk.regExp.NumSubexp()
+	case currentChunk.kind == static:
+		return literalRegExp(currentChunk.literal, foldCase), 0
+	}
+	return "[^/]+", 0
+}
+
+// literalRegExp returns a regular expression matching literal text,
+// case-insensitively if foldCase is true.
+func literalRegExp(literal string, foldCase bool) string {
+	if foldCase {
+		return "(?i:" + regexp.QuoteMeta(literal) + ")"
+	}
+	return regexp.QuoteMeta(literal)
+}
+
+// maxStackCaptures is the number of chunks a pattern can have before match
+// captures are allocated on the heap rather than the stack.
+const maxStackCaptures = 16
+
+// compileChunks prepares parsed chunks for segment matching. Empty chunks are
+// dropped, static chunks are reduced to their literal text where possible,
+// custom sub-patterns are compiled into anchored regular expressions, and
+// param types are resolved to their check functions.
+func compileChunks(chunks []chunk) ([]chunk, error) {
+	compiled := make([]chunk, 0, len(chunks))
+	for _, currentChunk := range chunks {
+		if currentChunk.kind == unknown {
+			continue
+		}
+
+		if currentChunk.kind == static {
+			if literal, ok := staticLiteral(currentChunk.pattern); ok {
+				currentChunk.literal = literal
+				compiled = append(compiled, currentChunk)
+				continue
+			}
+		}
+
+		if currentChunk.kind == mixed {
+			if currentChunk.modifier == oneOrMore || currentChunk.modifier == zeroOrMore {
+				return nil, errors.New("segments with several parts cannot repeat")
+			}
+			parts := make([]chunkPart, len(currentChunk.parts))
+			for i, part := range currentChunk.parts {
+				if part.kind == static {
+					literal, ok := staticLiteral(part.pattern)
+					if !ok {
+						return nil, errors.New("static text within a segment with params cannot contain regular expression syntax")
+					}
+					part.literal = literal
+				} else {
+					checkParamType, regExp, err := compileParamConstraints(part.paramType, part.pattern)
+					if err != nil {
+						return nil, err
+					}
+					part.checkParamType = checkParamType
+					part.regExp = regExp
+				}
+				parts[i] = part
+			}
+			currentChunk.parts = parts
+			compiled = append(compiled, currentChunk)
+			continue
+		}
+
+		checkParamType, regExp, err := compileParamConstraints(currentChunk.paramType, currentChunk.pattern)
+		if err != nil {
+			return nil, err
+		}
+		currentChunk.checkParamType = checkParamType
+		currentChunk.regExp = regExp
+
+		compiled = append(compiled, currentChunk)
+	}
+	return compiled, nil
+}
+
+// compileParamConstraints resolves a param type to its check function, and
+// compiles a custom sub-pattern into an anchored regular expression. Either
+// result is nil if it is not given.
+func compileParamConstraints(paramType, pattern string) (func(value string) bool, *regexp.Regexp, error) {
+	var checkParamType func(value string) bool
+	if paramType != "" {
+		var ok bool
+		checkParamType, ok = lookupParamType(paramType)
+		if !ok {
+			return nil, nil, errors.New("unknown param type `" + paramType + "`")
+		}
+	}
+
+	var regExp *regexp.Regexp
+	if pattern != "" {
+		var err error
+		regExp, err = regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return checkParamType, regExp, nil
+}
+
+// regExpSyntax holds the characters with special meaning in the regular
+// expressions of static chunks.
+const regExpSyntax = "\\+*?()|[]{}^$"
+
+// staticLiteral returns the literal text of a static chunk pattern with any
+// escaped characters unescaped. If the pattern contains regular expression
+// syntax, false is returned and the chunk must be matched with a regular
+// expression instead. Dots are treated as literal characters.
+func staticLiteral(pattern string) (string, bool) {
+	if !strings.ContainsAny(pattern, regExpSyntax) {
+		return pattern, true
+	}
+
+	literal := strings.Builder{}
+	isEscaped := false
+	for _, currentRune := range pattern {
+		if isEscaped {
+			isEscaped = false
+			if currentRune < 128 && (currentRune == '_' ||
+				currentRune >= '0' && currentRune <= '9' ||
+				currentRune >= 'a' && currentRune <= 'z' ||
+				currentRune >= 'A' && currentRune <= 'Z') {
+				return "", false
+			}
+			literal.WriteRune(currentRune)
+			continue
+		}
+		switch currentRune {
+		case '\\':
+			isEscaped = true
+			continue
+		case '+', '*', '?', '(', ')', '|', '[', ']', '{', '}', '^', '$':
+			return "", false
+		}
+		literal.WriteRune(currentRune)
+	}
+	if isEscaped {
+		return "", false
+	}
+
+	return literal.String(), true
+}
+
+// captureSlice returns a slice for holding the start and end offsets of each
+// chunk's match. The given buffer is used if it is large enough.
+func (p *Pattern) captureSlice(buf [][2]int) [][2]int {
+	if len(p.chunks) <= len(buf) {
+		return buf[:len(p.chunks)]
+	}
+	return make([][2]int, len(p.chunks))
+}
+
+// fillParams copies the captured values of the pattern's dynamic chunks, and
+// named wildcards, into params, percent-decoding them. Chunks that did not
+// participate in the match are set to an empty string.
+func (p *Pattern) fillParams(path string, captures [][2]int, params paramStore, foldCase bool) {
+	for i, currentChunk := range p.chunks {
+		if currentChunk.kind == mixed {
+			fillMixedChunkParams(&p.chunks[i], path, captures[i], params, foldCase)
+			continue
+		}
+		if currentChunk.kind != dynamic && (currentChunk.kind != wildcard || currentChunk.key == "") {
+			continue
+		}
+		if captures[i][0] < 0 {
+			params.setParam(currentChunk.paramIndex, currentChunk.key, "")
+			continue
+		}
+		params.setParam(currentChunk.paramIndex, currentChunk.key, unescapePathValue(path[captures[i][0]:captures[i][1]]))
+	}
+}
+
+// appendWildcards appends the values captured by the pattern's unnamed
+// wildcard chunks to wildcards, percent-decoding them if decode is true.
+// Chunks that did not participate in the match append an empty string.
+func (p *Pattern) appendWildcards(path string, captures [][2]int, wildcards []string, decode bool) []string {
+	for i, currentChunk := range p.chunks {
+		if currentChunk.kind != wildcard || currentChunk.key != "" {
+			continue
+		}
+		if captures[i][0] < 0 {
+			wildcards = append(wildcards, "")
+			continue
+		}
+		value := path[captures[i][0]:captures[i][1]]
+		if decode {
+			value = unescapePathValue(value)
+		}
+		wildcards = append(wildcards, value)
+	}
+	return wildcards
+}
+
+// fillMixedChunkParams copies the values of a mixed chunk's params into
+// params. The segment the chunk captured is matched against its parts again
+// to find where each param's value begins and ends.
+func fillMixedChunkParams(currentChunk *chunk, path string, capture [2]int, params paramStore, foldCase bool) {
+	if capture[0] < 0 {
+		for _, part := range currentChunk.parts {
+			if part.kind == dynamic {
+				params.setParam(part.paramIndex, part.key, "")
+			}
+		}
+		return
+	}
+
+	segment := unescapePathValue(path[capture[0]:capture[1]])
+	var endsBuf [maxStackParts]int
+	ends := partEndsSlice(endsBuf[:], len(currentChunk.parts))
+	matchChunkParts(currentChunk.parts, segment, 0, 0, foldCase, ends)
+
+	start := 0
+	for i, part := range currentChunk.parts {
+		if part.kind == dynamic {
+			params.setParam(part.paramIndex, part.key, segment[start:ends[i]])
+		}
+		start = ends[i]
+	}
+}
+
+// partEndsSlice returns a slice for holding the end offsets of the parts of a
+// mixed chunk. The given buffer is used if it is large enough.
+func partEndsSlice(buf []int, partCount int) []int {
+	if partCount <= len(buf) {
+		return buf[:partCount]
+	}
+	return make([]int, partCount)
+}
+
+// unescapePathValue percent-decodes a value taken from an escaped path.
+// Values without escapes are returned as is, without allocating, as are
+// values with invalid escapes.
+func unescapePathValue(value string) string {
+	if strings.IndexByte(value, '%') == -1 {
+		return value
+	}
+	unescaped, err := url.PathUnescape(value)
+	if err != nil {
+		return value
+	}
+	return unescaped
+}
+
+// match reports whether path matches the pattern, recording the offsets of
+// each chunk's match in captures. A single trailing slash is ignored unless
+// flags make trailing slashes strict.
+func (p *Pattern) match(path string, captures [][2]int, flags matchFlags) bool {
+	if p.isHost {
+		// Hosts have no leading separator, so matching begins at a virtual
+		// dot just before the first label. A trailing dot, as found in fully
+		// qualified names, is ignored.
+		return p.matchChunks(strings.TrimSuffix(path, "."), captures, 0, -1, true)
+	}
+	if flags&matchStrictTrailingSlash != 0 && !p.allowsTrailingSlash(path) {
+		return false
+	}
+	path = strings.TrimSuffix(path, "/")
+	if path != "" && path[0] != '/' {
+		return false
+	}
+	if p.spanRegExp != nil {
+		return p.matchSpanning(path, captures, p.foldCase(flags))
+	}
+	return p.matchChunks(path, captures, 0, 0, p.foldCase(flags))
+}
+
+// matchSpanning matches path against the whole path regular expression of a
+// pattern with sub-patterns that span segments, recording the offsets of
+// each chunk's match in captures. Typed params, and the params within mixed
+// segments, are then checked against their decoded values as they are when
+// matching segment by segment.
+func (p *Pattern) matchSpanning(path string, captures [][2]int, foldCase bool) bool {
+	regExp := p.spanRegExp
+	if foldCase {
+		regExp = p.spanFoldRegExp
+	}
+	indexes := regExp.FindStringSubmatchIndex(path)
+	if indexes == nil {
+		return false
+	}
+
+	for i, group := range p.chunkGroups {
+		captures[i] = [2]int{indexes[2*group], indexes[2*group+1]}
+		if captures[i][0] < 0 {
+			continue
+		}
+		if !checkSpanningCapture(&p.chunks[i], path[captures[i][0]:captures[i][1]], foldCase) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkSpanningCapture checks the value a chunk captured from a whole path
+// match against the chunk's param types, and splits the values of mixed
+// chunks into their parts. The values of repeating chunks are checked a
+// segment at a time.
+func checkSpanningCapture(currentChunk *chunk, value string, foldCase bool) bool {
+	if currentChunk.kind == mixed {
+		var endsBuf [maxStackParts]int
+		ends := partEndsSlice(endsBuf[:], len(currentChunk.parts))
+		return matchChunkParts(currentChunk.parts, unescapePathValue(value), 0, 0, foldCase, ends)
+	}
+	if currentChunk.checkParamType == nil {
+		return true
+	}
+	if currentChunk.modifier != oneOrMore && currentChunk.modifier != zeroOrMore {
+		return currentChunk.checkParamType(unescapePathValue(value))
+	}
+	for {
+		segment, rest, found := strings.Cut(value, "/")
+		if !currentChunk.checkParamType(unescapePathValue(segment)) {
+			return false
+		}
+		if !found {
+			return true
+		}
+		value = rest
+	}
+}
+
+// foldCase reports whether static segments are compared case-insensitively
+// when matching with the given flags. This is always the case for hosts.
+func (p *Pattern) foldCase(flags matchFlags) bool {
+	return p.isHost || flags&matchFoldCase != 0
+}
+
+// allowsTrailingSlash reports whether the presence or absence of a trailing
+// slash on path agrees with the pattern. Patterns ending with a repeating
+// chunk, such as the "**" routers are mounted with, allow either.
+func (p *Pattern) allowsTrailingSlash(path string) bool {
+	hasTrailingSlash := len(path) > 1 && path[len(path)-1] == '/'
+	if hasTrailingSlash == p.trailingSlash {
+		return true
+	}
+	if len(p.chunks) == 0 {
+		return false
+	}
+	modifier := p.chunks[len(p.chunks)-1].modifier
+	return modifier == oneOrMore || modifier == zeroOrMore
+}
+
+// matchChunks matches the pattern's chunks from chunkIndex onward against
+// path from pathIndex onward. pathIndex always points at a separator or the
+// end of the path. Chunks with repeating modifiers match greedily, backtracking
+// when the remaining chunks fail to match. Static segments are compared
+// case-insensitively if foldCase is true.
+func (p *Pattern) matchChunks(path string, captures [][2]int, chunkIndex, pathIndex int, foldCase bool) bool {
+	if chunkIndex == len(p.chunks) {
+		return pathIndex == len(path)
+	}
+	currentChunk := &p.chunks[chunkIndex]
+
+	switch currentChunk.modifier {
+	case single:
+		end, ok := matchChunkSegment(currentChunk, path, pathIndex, p.isHost, foldCase)
+		if !ok {
+			return false
+		}
+		captures[chunkIndex] = [2]int{pathIndex + 1, end}
+		return p.matchChunks(path, captures, chunkIndex+1, end, foldCase)
+
+	case optional:
+		if end, ok := matchChunkSegment(currentChunk, path, pathIndex, p.isHost, foldCase); ok {
+			captures[chunkIndex] = [2]int{pathIndex + 1, end}
+			if p.matchChunks(path, captures, chunkIndex+1, end, foldCase) {
+				return true
+			}
+		}
+		captures[chunkIndex] = [2]int{-1, -1}
+		return p.matchChunks(path, captures, chunkIndex+1, pathIndex, foldCase)
+
+	default:
+		minCount := 0
+		if currentChunk.modifier == oneOrMore {
+			minCount = 1
+		}
+
+		count := 0
+		end := pathIndex
+		for {
+			nextEnd, ok := matchChunkSegment(currentChunk, path, end, p.isHost, foldCase)
+			if !ok {
+				break
+			}
+			end = nextEnd
+			count += 1
+		}
+
+		for ; count >= minCount; count -= 1 {
+			if count == 0 {
+				captures[chunkIndex] = [2]int{-1, -1}
+			} else {
+				captures[chunkIndex] = [2]int{pathIndex + 1, end}
+			}
+			if p.matchChunks(path, captures, chunkIndex+1, end, foldCase) {
+				return true
+			}
+			if count > 0 {
+				end = strings.LastIndexByte(path[:end], separatorFor(p.isHost))
+			}
+		}
+		return false
+	}
+}
+
+// matchChunkSegment matches a chunk against the path segment following the
+// separator at pathIndex. It returns the index of the end of the segment, and
+// whether the chunk matched it. Empty segments never match, and segments for
+// typed params must pass their type's check. Segments are percent-decoded
+// before being compared or checked, so an encoded slash does not end them.
+// Host segments are separated by dots. Static segments are compared
+// case-insensitively if foldCase is true.
+func matchChunkSegment(currentChunk *chunk, path string, pathIndex int, isHost, foldCase bool) (int, bool) {
+	if pathIndex >= len(path) {
+		return 0, false
+	}
+	start := pathIndex + 1
+	end := strings.IndexByte(path[start:], separatorFor(isHost))
+	if end == -1 {
+		end = len(path)
+	} else {
+		end += start
+	}
+	segment := path[start:end]
+	if segment == "" {
+		return 0, false
+	}
+	segment = unescapePathValue(segment)
+
+	if currentChunk.kind == mixed {
+		var endsBuf [maxStackParts]int
+		ends := partEndsSlice(endsBuf[:], len(currentChunk.parts))
+		return end, matchChunkParts(currentChunk.parts, segment, 0, 0, foldCase, ends)
+	}
+
+	if currentChunk.regExp != nil && !currentChunk.regExp.MatchString(segment) {
+		return end, false
+	}
+	if currentChunk.checkParamType != nil {
+		return end, currentChunk.checkParamType(segment)
+	}
+	if currentChunk.regExp != nil {
+		return end, true
+	}
+	if currentChunk.kind == static {
+		if foldCase {
+			return end, strings.EqualFold(segment, currentChunk.literal)
+		}
+		return end, segment == currentChunk.literal
+	}
+	return end, true
+}
+
+// matchChunkParts matches the parts of a mixed chunk from partIndex onward
+// against segment from offset onward, recording the end offset of each
+// part's match in ends. Each param takes as much of the segment as it can
+// while the parts after it still match, and must take at least one
+// character.
+func matchChunkParts(parts []chunkPart, segment string, partIndex, offset int, foldCase bool, ends []int) bool {
+	if partIndex == len(parts) {
+		return offset == len(segment)
+	}
+	part := &parts[partIndex]
+
+	if part.kind == static {
+		end := offset + len(part.literal)
+		if end > len(segment) || !equalsLiteral(segment[offset:end], part.literal, foldCase) {
+			return false
+		}
+		ends[partIndex] = end
+		return matchChunkParts(parts, segment, partIndex+1, end, foldCase, ends)
+	}
+
+	var nextLiteral string
+	if partIndex+1 < len(parts) {
+		nextLiteral = parts[partIndex+1].literal
+	}
+	for end := len(segment); end > offset; end -= 1 {
+		if nextLiteral != "" && (len(segment)-end < len(nextLiteral) ||
+			!equalsLiteral(segment[end:end+len(nextLiteral)], nextLiteral, foldCase)) {
+			continue
+		}
+		value := segment[offset:end]
+		if part.regExp != nil && !part.regExp.MatchString(value) {
+			continue
+		}
+		if part.checkParamType != nil && !part.checkParamType(value) {
+			continue
+		}
+		ends[partIndex] = end
+		if matchChunkParts(parts, segment, partIndex+1, end, foldCase, ends) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalsLiteral compares text to a static literal, case-insensitively if
+// foldCase is true.
+func equalsLiteral(text, literal string, foldCase bool) bool {
+	if foldCase {
+		return strings.EqualFold(text, literal)
+	}
+	return text == literal
+}
+
+// separatorFor returns the byte separating segments of a host or a path.
+func separatorFor(isHost bool) byte {
+	if isHost {
+		return '.'
+	}
+	return '/'
+}
@@ -1,6 +1,7 @@
 package navaros_test
 
 import (
+	"strconv"
 	"strings"
 	"testing"
 
@@ -317,6 +318,228 @@ func TestPatternPath(t *testing.T) {
 	}
 }
 
+func TestPatternPathEscapesValues(t *testing.T) {
+	pattern, err := navaros.NewPattern("/buckets/:bucket/:key/files/:rest*")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path, err := pattern.Path(navaros.RequestParams{
+		"bucket": "my bucket",
+		"key":    "a/b?c",
+		"rest":   "x y/z",
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/buckets/my%20bucket/a%2Fb%3Fc/files/x%20y/z" {
+		t.Errorf("expected /buckets/my%%20bucket/a%%2Fb%%3Fc/files/x%%20y/z, got %s", path)
+	}
+
+	params, ok := pattern.Match(path)
+	if !ok {
+		t.Fatal("expected the built path to match")
+	}
+	if params["bucket"] != "my bucket" || params["key"] != "a/b?c" || params["rest"] != "x y/z" {
+		t.Errorf("expected decoded params, got %v", params)
+	}
+}
+
+func TestPatternMatchEncodedSlash(t *testing.T) {
+	pattern, err := navaros.NewPattern("/files/:key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	params, ok := pattern.Match("/files/a%2Fb")
+	if !ok {
+		t.Fatal("expected an encoded slash to stay within the segment")
+	}
+	if params["key"] != "a/b" {
+		t.Errorf("expected a/b, got %s", params["key"])
+	}
+	if _, ok := pattern.Match("/files/a/b"); ok {
+		t.Error("expected a literal slash to split the segment")
+	}
+
+	pattern, err = navaros.NewPattern("/café/:id<int>")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := pattern.Match("/caf%C3%A9/%31"); !ok {
+		t.Error("expected encoded static and typed segments to match")
+	}
+}
+
+func TestPatternMixedSegments(t *testing.T) {
+	cases := []struct {
+		patternStr     string
+		pathStr        string
+		shouldMatch    bool
+		expectedParams navaros.RequestParams
+	}{
+		{"/files/:name.:ext", "/files/report.pdf", true, navaros.RequestParams{"name": "report", "ext": "pdf"}},
+		{"/files/:name.:ext", "/files/archive.tar.gz", true, navaros.RequestParams{"name": "archive.tar", "ext": "gz"}},
+		{"/files/:name.:ext", "/files/README", false, nil},
+		{"/files/:name.:ext", "/files/.env", false, nil},
+		{"/v:major.:minor/status", "/v1.2/status", true, navaros.RequestParams{"major": "1", "minor": "2"}},
+		{"/v:major<int>.:minor<int>/status", "/v1.x/status", false, nil},
+		{"/@:username", "/@alice", true, navaros.RequestParams{"username": "alice"}},
+		{"/@:username", "/alice", false, nil},
+		{"/:from-:to(\\d+)", "/a-b-12", true, navaros.RequestParams{"from": "a-b", "to": "12"}},
+		{"/:from-:to(\\d+)", "/a-b", false, nil},
+		{"/posts/:slug.html?", "/posts", true, navaros.RequestParams{"slug": ""}},
+		{"/posts/:slug.html?", "/posts/hello.html", true, navaros.RequestParams{"slug": "hello"}},
+		{"/img/:w<int>x:h<int>.png", "/img/640x480.png", true, navaros.RequestParams{"w": "640", "h": "480"}},
+		{"/a\\:b", "/a:b", true, navaros.RequestParams{}},
+	}
+	for _, c := range cases {
+		pattern, err := navaros.NewPattern(c.patternStr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.patternStr, err)
+			continue
+		}
+		params, ok := pattern.Match(c.pathStr)
+		if ok != c.shouldMatch {
+			t.Errorf("%s: expected match of %s to be %v", c.patternStr, c.pathStr, c.shouldMatch)
+			continue
+		}
+		for key, value := range c.expectedParams {
+			if params[key] != value {
+				t.Errorf("%s: expected %s to be %q, got %q", c.patternStr, key, value, params[key])
+			}
+		}
+	}
+}
+
+func TestPatternSubPatternsSpanningSegments(t *testing.T) {
+	cases := []struct {
+		patternStr     string
+		pathStr        string
+		shouldMatch    bool
+		expectedParams navaros.RequestParams
+	}{
+		{"/files/:path(.*)", "/files/a/b/c", true, navaros.RequestParams{"path": "a/b/c"}},
+		{"/files/:path(.+)", "/files/a/b", true, navaros.RequestParams{"path": "a/b"}},
+		{"/files/:path(.+)", "/files/a/b/", true, navaros.RequestParams{"path": "a/b"}},
+		{"/files/:path(.+)", "/files", false, nil},
+		{"/a/(.*)", "/a/x/y", true, navaros.RequestParams{}},
+		{"/files/:path(.+)/raw", "/files/a/b/raw", true, navaros.RequestParams{"path": "a/b"}},
+		{"/files/:path(.+)/raw", "/files/a/b", false, nil},
+		{"/files/:path([a-z/]+)/:id<int>", "/files/a/b/12", true, navaros.RequestParams{"path": "a/b", "id": "12"}},
+		{"/files/:path([a-z/]+)/:id<int>", "/files/a/b/c", false, nil},
+		{"/docs/:path(.+).:ext", "/docs/guide/intro.md", true, navaros.RequestParams{"path": "guide/intro", "ext": "md"}},
+		{"/(x|y)/:rest(a/b|c)", "/y/a/b", true, navaros.RequestParams{"rest": "a/b"}},
+	}
+	for _, c := range cases {
+		pattern, err := navaros.NewPattern(c.patternStr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.patternStr, err)
+			continue
+		}
+		params, ok := pattern.Match(c.pathStr)
+		if ok != c.shouldMatch {
+			t.Errorf("%s: expected match of %s to be %v", c.patternStr, c.pathStr, c.shouldMatch)
+			continue
+		}
+		for key, value := range c.expectedParams {
+			if params[key] != value {
+				t.Errorf("%s: expected %s to be %q, got %q", c.patternStr, key, value, params[key])
+			}
+		}
+	}
+}
+
+func TestPatternMixedSegmentsPath(t *testing.T) {
+	pattern, err := navaros.NewPattern("/files/:name.:ext/v:version?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path, err := pattern.Path(navaros.RequestParams{"name": "my report", "ext": "pdf", "version": "2"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/files/my%20report.pdf/v2" {
+		t.Errorf("expected /files/my%%20report.pdf/v2, got %s", path)
+	}
+	path, err = pattern.Path(navaros.RequestParams{"name": "a", "ext": "b"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/files/a.b" {
+		t.Errorf("expected /files/a.b, got %s", path)
+	}
+	if _, err := pattern.Path(navaros.RequestParams{"name": "a"}, nil); err == nil {
+		t.Error("expected an error for a missing parameter")
+	}
+}
+
+func TestPatternInvalidMixedSegments(t *testing.T) {
+	for _, patternStr := range []string{"/:a:b", "/:name.:ext+", "/a:b.(c|d)"} {
+		if _, err := navaros.NewPattern(patternStr); err == nil {
+			t.Errorf("expected an error for %s", patternStr)
+		}
+	}
+}
+
+func TestPatternMatchWithWildcards(t *testing.T) {
+	pattern, err := navaros.NewPattern("/a/*/b/*?/c/**")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, wildcards, ok := pattern.MatchWithWildcards("/a/1/b/c/x%20y/z")
+	if !ok {
+		t.Fatal("expected the path to match")
+	}
+	if len(wildcards) != 3 || wildcards[0] != "1" || wildcards[1] != "" || wildcards[2] != "x y/z" {
+		t.Errorf("expected [1  x y/z], got %q", wildcards)
+	}
+
+	path, err := pattern.Path(nil, wildcards)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/a/1/b/c/x%20y/z" {
+		t.Errorf("expected the wildcards to round trip, got %s", path)
+	}
+
+	if _, _, ok := pattern.MatchWithWildcards("/a/1/b"); ok {
+		t.Error("expected the path not to match")
+	}
+}
+
+func TestPatternNamedWildcards(t *testing.T) {
+	pattern, err := navaros.NewPattern("/static/**path")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	params, wildcards, ok := pattern.MatchWithWildcards("/static/css/site.css")
+	if !ok {
+		t.Fatal("expected the path to match")
+	}
+	if params["path"] != "css/site.css" {
+		t.Errorf("expected css/site.css, got %s", params["path"])
+	}
+	if len(wildcards) != 0 {
+		t.Errorf("expected named wildcards not to be in the wildcards, got %q", wildcards)
+	}
+	path, err := pattern.Path(params, nil)
+	if err != nil || path != "/static/css/site.css" {
+		t.Errorf("expected /static/css/site.css, got %s (%v)", path, err)
+	}
+
+	pattern, err = navaros.NewPattern("/files/*name/info")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	params, ok = pattern.Match("/files/report/info")
+	if !ok || params["name"] != "report" {
+		t.Errorf("expected report, got %v", params)
+	}
+	if _, ok := pattern.Match("/files/a/b/info"); ok {
+		t.Error("expected a single star to match one segment")
+	}
+}
+
 func TestPatternRootPath(t *testing.T) {
 	pattern, err := navaros.NewPattern("/")
 	if err != nil {
@@ -421,3 +644,171 @@ func TestPatternEmptyParamName(t *testing.T) {
 		t.Error("expected error for empty param name")
 	}
 }
+
+func TestHostPatternMatch(t *testing.T) {
+	pattern, err := navaros.NewHostPattern(":tenant.api.example.com")
+	if err != nil {
+		t.Fatalf("failed to create host pattern: %v", err)
+	}
+
+	params, matched := pattern.Match("acme.API.example.com")
+	if !matched {
+		t.Fatal("expected acme.API.example.com to match")
+	}
+	if params.Get("tenant") != "acme" {
+		t.Errorf("expected tenant=acme, got %s", params.Get("tenant"))
+	}
+
+	if _, matched := pattern.Match("api.example.com"); matched {
+		t.Error("expected api.example.com not to match")
+	}
+	if _, matched := pattern.Match("acme.api.example.org"); matched {
+		t.Error("expected acme.api.example.org not to match")
+	}
+}
+
+func TestHostPatternWildcard(t *testing.T) {
+	pattern, err := navaros.NewHostPattern("**.example.com")
+	if err != nil {
+		t.Fatalf("failed to create host pattern: %v", err)
+	}
+
+	for _, host := range []string{"example.com", "www.example.com", "a.b.example.com", "example.com."} {
+		if _, matched := pattern.Match(host); !matched {
+			t.Errorf("expected %s to match", host)
+		}
+	}
+	if _, matched := pattern.Match("example.org"); matched {
+		t.Error("expected example.org not to match")
+	}
+}
+
+func TestHostPatternCustomSubPattern(t *testing.T) {
+	pattern, err := navaros.NewHostPattern(`:region(us|eu).cdn.example.com`)
+	if err != nil {
+		t.Fatalf("failed to create host pattern: %v", err)
+	}
+
+	params, matched := pattern.Match("eu.cdn.example.com")
+	if !matched {
+		t.Fatal("expected eu.cdn.example.com to match")
+	}
+	if params.Get("region") != "eu" {
+		t.Errorf("expected region=eu, got %s", params.Get("region"))
+	}
+	if _, matched := pattern.Match("ap.cdn.example.com"); matched {
+		t.Error("expected ap.cdn.example.com not to match")
+	}
+}
+
+func TestHostPatternPath(t *testing.T) {
+	pattern, err := navaros.NewHostPattern(":tenant.example.com")
+	if err != nil {
+		t.Fatalf("failed to create host pattern: %v", err)
+	}
+
+	host, err := pattern.Path(navaros.RequestParams{"tenant": "acme"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if host != "acme.example.com" {
+		t.Errorf("expected acme.example.com, got %s", host)
+	}
+}
+
+func TestHostPatternInvalid(t *testing.T) {
+	if _, err := navaros.NewHostPattern(""); err == nil {
+		t.Error("expected error for empty host pattern")
+	}
+	if _, err := navaros.NewHostPattern("example.com/path"); err == nil {
+		t.Error("expected error for host pattern with a slash")
+	}
+}
+
+func TestPatternParamTypes(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		matches bool
+	}{
+		{"/users/:id<int>", "/users/123", true},
+		{"/users/:id<int>", "/users/-5", true},
+		{"/users/:id<int>", "/users/abc", false},
+		{"/users/:id<uint>", "/users/-5", false},
+		{"/prices/:amount<float>", "/prices/9.99", true},
+		{"/prices/:amount<float>", "/prices/-2.5e3", true},
+		{"/prices/:amount<float>", "/prices/NaN", false},
+		{"/prices/:amount<float>", "/prices/Inf", false},
+		{"/prices/:amount<float>", "/prices/0x1p-2", false},
+		{"/prices/:amount<float>", "/prices/1_000", false},
+		{"/tags/:slug<alpha>", "/tags/golang", true},
+		{"/tags/:slug<alpha>", "/tags/go1", false},
+		{"/tags/:slug<alnum>", "/tags/go1", true},
+		{"/items/:id<uuid>", "/items/123e4567-e89b-12d3-a456-426614174000", true},
+		{"/items/:id<uuid>", "/items/123e4567e89b12d3a456426614174000", false},
+		{"/events/:at<date>", "/events/2024-02-29", true},
+		{"/events/:at<date>", "/events/2023-02-29", false},
+		{"/users/:id<int>?", "/users", true},
+		{"/ids/:ids<int>+", "/ids/1/2/3", true},
+		{"/ids/:ids<int>+", "/ids/1/x/3", false},
+		{"/codes/:code<int>(\\d{3})", "/codes/404", true},
+		{"/codes/:code<int>(\\d{3})", "/codes/4040", false},
+	}
+
+	for _, c := range cases {
+		pattern, err := navaros.NewPattern(c.pattern)
+		if err != nil {
+			t.Fatalf("failed to create pattern %s: %v", c.pattern, err)
+		}
+		if _, matched := pattern.Match(c.path); matched != c.matches {
+			t.Errorf("expected %s matching %s to be %v", c.pattern, c.path, c.matches)
+		}
+	}
+}
+
+func TestPatternParamTypeKeepsParamName(t *testing.T) {
+	pattern, err := navaros.NewPattern("/users/:id<int>")
+	if err != nil {
+		t.Fatalf("failed to create pattern: %v", err)
+	}
+
+	params, matched := pattern.Match("/users/42")
+	if !matched {
+		t.Fatal("expected to match")
+	}
+	if params.Get("id") != "42" {
+		t.Errorf("expected id=42, got %s", params.Get("id"))
+	}
+}
+
+func TestPatternInvalidParamTypes(t *testing.T) {
+	for _, patternStr := range []string{
+		"/users/:id<nope>",
+		"/users/:id<int",
+		"/users/:id<>",
+		"/users/:<int>",
+		"/users/:id(\\d+)<int>",
+	} {
+		if _, err := navaros.NewPattern(patternStr); err == nil {
+			t.Errorf("expected error for %s", patternStr)
+		}
+	}
+}
+
+func TestRegisterParamType(t *testing.T) {
+	navaros.RegisterParamType("even", func(value string) bool {
+		i, err := strconv.Atoi(value)
+		return err == nil && i%2 == 0
+	})
+
+	pattern, err := navaros.NewPattern("/numbers/:n<even>")
+	if err != nil {
+		t.Fatalf("failed to create pattern: %v", err)
+	}
+	if _, matched := pattern.Match("/numbers/4"); !matched {
+		t.Error("expected /numbers/4 to match")
+	}
+	if _, matched := pattern.Match("/numbers/3"); matched {
+		t.Error("expected /numbers/3 not to match")
+	}
+}
@@ -1,6 +1,12 @@
 package navaros
 
-import "strings"
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // RequestParams represents the parameters extracted from the request path.
 // Parameters are extracted from the path by matching the request path to
@@ -9,8 +15,12 @@ import "strings"
 type RequestParams map[string]string
 
 // Get returns the value of a given parameter key. If the key does not exist,
-// an empty string is returned.
+// an empty string is returned. Keys that don't match exactly are compared
+// case-insensitively.
 func (p RequestParams) Get(key string) string {
+	if value, ok := p[key]; ok {
+		return value
+	}
 	for k, v := range p {
 		if strings.EqualFold(k, key) {
 			return v
@@ -18,3 +28,111 @@ func (p RequestParams) Get(key string) string {
 	}
 	return ""
 }
+
+// Int returns the value of a given parameter key parsed as an int. An error
+// is returned if the key does not exist or its value is not an integer. Use
+// the int param type, as in "/users/:id<int>", to ensure routes only match
+// when the value can be parsed.
+func (p RequestParams) Int(key string) (int, error) {
+	value, err := p.lookup(key, "int")
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, invalidParamError(key, "int", err)
+	}
+	return i, nil
+}
+
+// Int64 returns the value of a given parameter key parsed as an int64. An
+// error is returned if the key does not exist or its value is not an integer.
+func (p RequestParams) Int64(key string) (int64, error) {
+	value, err := p.lookup(key, "int64")
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, invalidParamError(key, "int64", err)
+	}
+	return i, nil
+}
+
+// Uint64 returns the value of a given parameter key parsed as a uint64. An
+// error is returned if the key does not exist or its value is not an unsigned
+// integer. Pair with the uint param type.
+func (p RequestParams) Uint64(key string) (uint64, error) {
+	value, err := p.lookup(key, "uint64")
+	if err != nil {
+		return 0, err
+	}
+	u, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, invalidParamError(key, "uint64", err)
+	}
+	return u, nil
+}
+
+// Float64 returns the value of a given parameter key parsed as a float64. An
+// error is returned if the key does not exist or its value is not a decimal
+// number. Pair with the float param type.
+func (p RequestParams) Float64(key string) (float64, error) {
+	value, err := p.lookup(key, "float64")
+	if err != nil {
+		return 0, err
+	}
+	f, err := parseDecimalFloat(value)
+	if err != nil {
+		return 0, invalidParamError(key, "float64", err)
+	}
+	return f, nil
+}
+
+// Date returns the value of a given parameter key parsed as a date in the
+// DateParamLayout format. An error is returned if the key does not exist or
+// its value is not a date. Pair with the date param type.
+func (p RequestParams) Date(key string) (time.Time, error) {
+	value, err := p.lookup(key, "date")
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(DateParamLayout, value)
+	if err != nil {
+		return time.Time{}, invalidParamError(key, "date", err)
+	}
+	return t, nil
+}
+
+// lookup returns the value of a given parameter key, or an error if the key
+// does not exist or is empty.
+func (p RequestParams) lookup(key, typeName string) (string, error) {
+	value := p.Get(key)
+	if value == "" {
+		return "", errors.New("missing " + typeName + " parameter `" + key + "`")
+	}
+	return value, nil
+}
+
+// paramStore receives the params captured by a pattern when it matches.
+// RequestParams is a paramStore, as is the context's own params storage.
+type paramStore interface {
+	// resetParams removes the params held, before those captured by the
+	// pattern are set.
+	resetParams(pattern *Pattern)
+	// setParam sets a param captured by a pattern. index is the param's
+	// capture index within the pattern.
+	setParam(index int, key, value string)
+}
+
+func (p RequestParams) resetParams(*Pattern) {
+	clear(p)
+}
+
+func (p RequestParams) setParam(_ int, key, value string) {
+	p[key] = value
+}
+
+func invalidParamError(key, typeName string, err error) error {
+	return fmt.Errorf("invalid %s parameter `%s`: %w", typeName, key, err)
+}
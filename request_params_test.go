@@ -2,6 +2,7 @@ package navaros_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/RobertWHurst/navaros"
 )
@@ -36,3 +37,42 @@ func TestRequestParamsGet(t *testing.T) {
 		})
 	}
 }
+
+func TestRequestParamsTypedGetters(t *testing.T) {
+	params := navaros.RequestParams{
+		"id":    "42",
+		"big":   "9000000000",
+		"price": "9.99",
+		"at":    "2024-02-29",
+		"name":  "alice",
+	}
+
+	if i, err := params.Int("id"); err != nil || i != 42 {
+		t.Errorf("expected 42, got %d (%v)", i, err)
+	}
+	if i, err := params.Int64("big"); err != nil || i != 9000000000 {
+		t.Errorf("expected 9000000000, got %d (%v)", i, err)
+	}
+	if u, err := params.Uint64("id"); err != nil || u != 42 {
+		t.Errorf("expected 42, got %d (%v)", u, err)
+	}
+	if f, err := params.Float64("price"); err != nil || f != 9.99 {
+		t.Errorf("expected 9.99, got %f (%v)", f, err)
+	}
+	if d, err := params.Date("at"); err != nil || !d.Equal(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected 2024-02-29, got %s (%v)", d, err)
+	}
+
+	if _, err := params.Int("name"); err == nil {
+		t.Error("expected an error for a non integer value")
+	}
+	if _, err := params.Int("missing"); err == nil {
+		t.Error("expected an error for a missing key")
+	}
+	if _, err := (navaros.RequestParams{"price": "NaN"}).Float64("price"); err == nil {
+		t.Error("expected an error for a non decimal value")
+	}
+	if _, err := params.Date("name"); err == nil {
+		t.Error("expected an error for a non date value")
+	}
+}
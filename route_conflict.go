@@ -0,0 +1,416 @@
+package navaros
+
+import (
+	"strconv"
+	"strings"
+)
+
+// RouteConflictKind describes how a route conflicts with a route registered
+// before it.
+type RouteConflictKind string
+
+const (
+	// DuplicateRoute is reported for a route that matches exactly the same
+	// requests as a route registered before it.
+	DuplicateRoute RouteConflictKind = "duplicate"
+	// ShadowedRoute is reported for a route that can never be reached because
+	// a route registered before it matches every request it would.
+	ShadowedRoute RouteConflictKind = "shadowed"
+	// AmbiguousRoute is reported for a route that overlaps with a route
+	// registered before it, where neither is more general than the other.
+	// Requests matching both are handled by the earlier route.
+	AmbiguousRoute RouteConflictKind = "ambiguous"
+)
+
+// RouteConflict describes a route that conflicts with a route registered
+// before it in the same router. CallSite and ConflictingCallSite hold the
+// file and line each route was bound at.
+type RouteConflict struct {
+	Kind                RouteConflictKind
+	Method              HTTPMethod
+	Host                *Pattern
+	Pattern             *Pattern
+	Version             string
+	CallSite            string
+	ConflictingHost     *Pattern
+	ConflictingPattern  *Pattern
+	ConflictingVersion  string
+	ConflictingCallSite string
+}
+
+// String returns a description of the conflict.
+func (c RouteConflict) String() string {
+	route := describeRoute(c.Method, c.Host, c.Pattern, c.Version, c.CallSite)
+	conflictingRoute := describeRoute(c.Method, c.ConflictingHost, c.ConflictingPattern, c.ConflictingVersion, c.ConflictingCallSite)
+	switch c.Kind {
+	case DuplicateRoute:
+		return route + " duplicates " + conflictingRoute
+	case ShadowedRoute:
+		return route + " is shadowed by " + conflictingRoute + " and can never be reached"
+	default:
+		return route + " is ambiguous with " + conflictingRoute + ", which handles requests matching both"
+	}
+}
+
+// RouteConflicts is the error returned by Router.Validate when conflicting
+// routes are found.
+type RouteConflicts []RouteConflict
+
+// Error returns a description of every conflict.
+func (c RouteConflicts) Error() string {
+	builder := strings.Builder{}
+	builder.WriteString("found " + strconv.Itoa(len(c)) + " route conflict")
+	if len(c) != 1 {
+		builder.WriteString("s")
+	}
+	builder.WriteString(":")
+	for _, conflict := range c {
+		builder.WriteString("\n  - " + conflict.String())
+	}
+	return builder.String()
+}
+
+// Validate checks the router's routes for conflicts. Each route is compared
+// with the routes registered before it with the same method, and duplicate,
+// shadowed, and ambiguous routes are reported. Routers mounted on this one
+// are validated as well. Routes bound to different hosts, or to different API
+// versions, do not conflict with each other. Handlers bound with Use or All
+// are not considered, as they are usually middleware which passes requests
+// on.
+//
+// Validate only inspects patterns, so a route reported as shadowed may still
+// be reached if the route before it calls ctx.Next(). Segments are assumed to
+// overlap unless they provably cannot, so routes with different custom
+// sub-patterns are reported as ambiguous unless their literal text keeps
+// them apart. Static segments are compared case-insensitively if the router
+// matches them that way. Sub-patterns that can match a slash are compared
+// as if they matched a single segment, so conflicts with the routes they
+// span are not reported. It returns nil if no conflicts are found, or
+// RouteConflicts otherwise. It's intended to be called from tests, or once
+// at startup.
+func (r *Router) Validate() error {
+	conflicts := r.appendRouteConflicts(nil, map[*Router]bool{})
+	if len(conflicts) == 0 {
+		return nil
+	}
+	return conflicts
+}
+
+// appendRouteConflicts appends the conflicts between the router's routes, and
+// those of its mounted routers, to conflicts.
+func (r *Router) appendRouteConflicts(conflicts RouteConflicts, visited map[*Router]bool) RouteConflicts {
+	visited[r] = true
+
+	var routeHandlerNodes []*HandlerNode
+	for _, handlerNode := range r.currentSnapshot().handlerNodes {
+		isMount := false
+		for _, handlerOrTransformer := range handlerNode.HandlersAndTransformers {
+			if _, ok := handlerOrTransformer.(RouterHandler); ok {
+				isMount = true
+			}
+			if router, ok := handlerOrTransformer.(*Router); ok && !visited[router] {
+				conflicts = router.appendRouteConflicts(conflicts, visited)
+			}
+		}
+		if isMount || handlerNode.Method == All || handlerNode.Pattern == nil {
+			continue
+		}
+
+		for _, earlierHandlerNode := range routeHandlerNodes {
+			if earlierHandlerNode.Method != handlerNode.Method {
+				continue
+			}
+			kind, ok := routeConflictKind(earlierHandlerNode, handlerNode, r.caseInsensitive)
+			if !ok {
+				continue
+			}
+			conflicts = append(conflicts, RouteConflict{
+				Kind:                kind,
+				Method:              handlerNode.Method,
+				Host:                handlerNode.Host,
+				Pattern:             handlerNode.Pattern,
+				Version:             handlerNode.Version,
+				CallSite:            handlerNode.callSite,
+				ConflictingHost:     earlierHandlerNode.Host,
+				ConflictingPattern:  earlierHandlerNode.Pattern,
+				ConflictingVersion:  earlierHandlerNode.Version,
+				ConflictingCallSite: earlierHandlerNode.callSite,
+			})
+			if kind != AmbiguousRoute {
+				break
+			}
+		}
+		routeHandlerNodes = append(routeHandlerNodes, handlerNode)
+	}
+
+	return conflicts
+}
+
+// routeConflictKind determines how a route conflicts with one registered
+// before it, if at all. If foldCase is true, static segments are compared
+// case-insensitively.
+func routeConflictKind(earlier, later *HandlerNode, foldCase bool) (RouteConflictKind, bool) {
+	earlierCoversTarget, laterCoversTarget := true, true
+	switch {
+	case earlier.Host == nil && later.Host == nil:
+	case earlier.Host == nil:
+		laterCoversTarget = false
+	case later.Host == nil:
+		earlierCoversTarget = false
+	case earlier.Host.String() != later.Host.String():
+		return "", false
+	}
+
+	// Routes without a version match requests for every version.
+	switch {
+	case earlier.Version == later.Version:
+	case earlier.Version == "":
+		laterCoversTarget = false
+	case later.Version == "":
+		earlierCoversTarget = false
+	default:
+		return "", false
+	}
+
+	if !chunksOverlap(earlier.Pattern.chunks, later.Pattern.chunks, foldCase) {
+		return "", false
+	}
+	earlierCovers := earlierCoversTarget && chunksCover(earlier.Pattern.chunks, later.Pattern.chunks, foldCase)
+	laterCovers := laterCoversTarget && chunksCover(later.Pattern.chunks, earlier.Pattern.chunks, foldCase)
+
+	switch {
+	case earlierCovers && laterCovers:
+		return DuplicateRoute, true
+	case earlierCovers:
+		return ShadowedRoute, true
+	case laterCovers:
+		// The earlier route is more specific, which is the intended order.
+		return "", false
+	default:
+		return AmbiguousRoute, true
+	}
+}
+
+// chunksCover reports whether every path matched by chunks b is also matched
+// by chunks a.
+func chunksCover(a, b []chunk, foldCase bool) bool {
+	accepts := func(a, b *chunk) bool {
+		return chunkCoversSegment(a, b, foldCase)
+	}
+	return !walkChunkShapes(b, len(a)+1, nil, func(symbols []*chunk) bool {
+		return !matchChunkSymbols(a, symbols, 0, 0, accepts)
+	})
+}
+
+// chunksOverlap reports whether any path may be matched by both chunks a and
+// b.
+func chunksOverlap(a, b []chunk, foldCase bool) bool {
+	accepts := func(a, b *chunk) bool {
+		return chunksOverlapSegment(a, b, foldCase)
+	}
+	return walkChunkShapes(b, len(a)+1, nil, func(symbols []*chunk) bool {
+		return matchChunkSymbols(a, symbols, 0, 0, accepts)
+	})
+}
+
+// walkChunkShapes calls visit with each sequence of segments that chunks can
+// match, where each segment is represented by the chunk that matches it.
+// Repeating chunks are expanded up to maxRepeat times. Walking stops as soon
+// as visit returns true, in which case true is returned.
+func walkChunkShapes(chunks []chunk, maxRepeat int, symbols []*chunk, visit func(symbols []*chunk) bool) bool {
+	if len(chunks) == 0 {
+		return visit(symbols)
+	}
+	currentChunk := &chunks[0]
+
+	minCount, maxCount := 1, 1
+	switch currentChunk.modifier {
+	case optional:
+		minCount = 0
+	case oneOrMore:
+		maxCount = maxRepeat
+	case zeroOrMore:
+		minCount, maxCount = 0, maxRepeat
+	}
+
+	for count := minCount; count <= maxCount; count += 1 {
+		nextSymbols := symbols
+		for i := 0; i < count; i += 1 {
+			nextSymbols = append(nextSymbols, currentChunk)
+		}
+		if walkChunkShapes(chunks[1:], maxRepeat, nextSymbols, visit) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchChunkSymbols matches chunks from chunkIndex onward against a sequence
+// of segment symbols from symbolIndex onward, in the same way matchChunks
+// matches them against path segments. accepts decides whether a chunk
+// matches a symbol.
+func matchChunkSymbols(chunks []chunk, symbols []*chunk, chunkIndex, symbolIndex int, accepts func(a, b *chunk) bool) bool {
+	if chunkIndex == len(chunks) {
+		return symbolIndex == len(symbols)
+	}
+	currentChunk := &chunks[chunkIndex]
+
+	switch currentChunk.modifier {
+	case single:
+		return symbolIndex < len(symbols) &&
+			accepts(currentChunk, symbols[symbolIndex]) &&
+			matchChunkSymbols(chunks, symbols, chunkIndex+1, symbolIndex+1, accepts)
+
+	case optional:
+		if symbolIndex < len(symbols) &&
+			accepts(currentChunk, symbols[symbolIndex]) &&
+			matchChunkSymbols(chunks, symbols, chunkIndex+1, symbolIndex+1, accepts) {
+			return true
+		}
+		return matchChunkSymbols(chunks, symbols, chunkIndex+1, symbolIndex, accepts)
+
+	default:
+		minCount := 0
+		if currentChunk.modifier == oneOrMore {
+			minCount = 1
+		}
+		count := 0
+		for symbolIndex+count < len(symbols) && accepts(currentChunk, symbols[symbolIndex+count]) {
+			count += 1
+		}
+		for ; count >= minCount; count -= 1 {
+			if matchChunkSymbols(chunks, symbols, chunkIndex+1, symbolIndex+count, accepts) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// chunkCoversSegment reports whether chunk a matches every segment chunk b
+// does. When that cannot be shown, false is returned.
+func chunkCoversSegment(a, b *chunk, foldCase bool) bool {
+	if isUnconstrainedChunk(a) {
+		return true
+	}
+	if isLiteralChunk(b) {
+		return chunkMatchesSegment(a, b.literal, foldCase)
+	}
+	if isLiteralChunk(a) || isUnconstrainedChunk(b) {
+		return false
+	}
+	if chunkConstraint(a) == chunkConstraint(b) {
+		return true
+	}
+	if isTypedChunk(a) && isTypedChunk(b) {
+		return paramTypeCovers(a.paramType, b.paramType)
+	}
+	return false
+}
+
+// chunksOverlapSegment reports whether any segment may be matched by both
+// chunks a and b. Only chunks which provably match no segment in common are
+// reported as not overlapping.
+func chunksOverlapSegment(a, b *chunk, foldCase bool) bool {
+	if isUnconstrainedChunk(a) || isUnconstrainedChunk(b) {
+		return true
+	}
+	if isLiteralChunk(a) {
+		return chunkMatchesSegment(b, a.literal, foldCase)
+	}
+	if isLiteralChunk(b) {
+		return chunkMatchesSegment(a, b.literal, foldCase)
+	}
+	if chunkConstraint(a) == chunkConstraint(b) {
+		return true
+	}
+	if isTypedChunk(a) && isTypedChunk(b) && paramTypesAreDisjoint(a.paramType, b.paramType) {
+		return false
+	}
+
+	// Segments matched by mixed chunks start and end with the chunk's static
+	// text, if it has any, so chunks with conflicting static text cannot
+	// match the same segment.
+	aPrefix, aSuffix := chunkAffixes(a, foldCase)
+	bPrefix, bSuffix := chunkAffixes(b, foldCase)
+	if !strings.HasPrefix(aPrefix, bPrefix) && !strings.HasPrefix(bPrefix, aPrefix) {
+		return false
+	}
+	if !strings.HasSuffix(aSuffix, bSuffix) && !strings.HasSuffix(bSuffix, aSuffix) {
+		return false
+	}
+	return true
+}
+
+// isUnconstrainedChunk reports whether a chunk matches any segment.
+func isUnconstrainedChunk(c *chunk) bool {
+	return c.kind != static && c.kind != mixed && c.regExp == nil && c.checkParamType == nil
+}
+
+// isLiteralChunk reports whether a chunk only matches its literal text.
+func isLiteralChunk(c *chunk) bool {
+	return c.kind == static && c.regExp == nil
+}
+
+// isTypedChunk reports whether a chunk is a param constrained only by a
+// param type.
+func isTypedChunk(c *chunk) bool {
+	return c.kind != static && c.kind != mixed && c.regExp == nil && c.checkParamType != nil
+}
+
+// chunkAffixes returns the static text a mixed chunk starts and ends with.
+// Both are empty for other chunks. If foldCase is true, they are lower cased.
+func chunkAffixes(c *chunk, foldCase bool) (string, string) {
+	if c.kind != mixed || len(c.parts) == 0 {
+		return "", ""
+	}
+	prefix, suffix := "", ""
+	if c.parts[0].kind == static {
+		prefix = c.parts[0].literal
+	}
+	if lastPart := c.parts[len(c.parts)-1]; lastPart.kind == static {
+		suffix = lastPart.literal
+	}
+	if foldCase {
+		return strings.ToLower(prefix), strings.ToLower(suffix)
+	}
+	return prefix, suffix
+}
+
+// chunkConstraint returns a key identifying the segments a constrained chunk
+// matches.
+func chunkConstraint(c *chunk) string {
+	if c.kind == mixed {
+		constraint := ""
+		for _, part := range c.parts {
+			if part.kind == static {
+				constraint += part.literal
+			} else {
+				constraint += ":" + part.pattern + "<" + part.paramType + ">"
+			}
+		}
+		return constraint
+	}
+	return c.pattern + "<" + c.paramType + ">"
+}
+
+// chunkMatchesSegment reports whether a chunk matches the given segment.
+func chunkMatchesSegment(c *chunk, segment string, foldCase bool) bool {
+	_, ok := matchChunkSegment(c, "/"+segment, 0, false, foldCase)
+	return ok
+}
+
+// describeRoute formats a route for a conflict description.
+func describeRoute(method HTTPMethod, host, pattern *Pattern, version, callSite string) string {
+	route := string(method) + " " + pattern.String()
+	if host != nil {
+		route += " on host " + host.String()
+	}
+	if version != "" {
+		route += " for version " + version
+	}
+	if callSite != "" {
+		route += " (" + callSite + ")"
+	}
+	return route
+}
@@ -0,0 +1,200 @@
+package navaros_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/RobertWHurst/navaros"
+)
+
+func validateConflicts(t *testing.T, router *navaros.Router) navaros.RouteConflicts {
+	t.Helper()
+	err := router.Validate()
+	if err == nil {
+		return nil
+	}
+	var conflicts navaros.RouteConflicts
+	if !errors.As(err, &conflicts) {
+		t.Fatalf("expected RouteConflicts, got %T", err)
+	}
+	return conflicts
+}
+
+func TestRouterValidateNoConflicts(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Use(func(ctx *navaros.Context) { ctx.Next() })
+	router.Get("/users/me", func(ctx *navaros.Context) {})
+	router.Get("/users/:id<int>", func(ctx *navaros.Context) {})
+	router.Get("/users/:id", func(ctx *navaros.Context) {})
+	router.Post("/users/:id", func(ctx *navaros.Context) {})
+	router.Get("/posts/:id<int>", func(ctx *navaros.Context) {})
+	router.Get("/posts/:slug<alpha>", func(ctx *navaros.Context) {})
+	router.Get("/files/:path+", func(ctx *navaros.Context) {})
+
+	if err := router.Validate(); err != nil {
+		t.Errorf("expected no conflicts, got %v", err)
+	}
+}
+
+func TestRouterValidateShadowedRoute(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/users/:id", func(ctx *navaros.Context) {})
+	router.Get("/users/me", func(ctx *navaros.Context) {})
+	router.Get("/files/**", func(ctx *navaros.Context) {})
+	router.Get("/files/:dir/:name", func(ctx *navaros.Context) {})
+
+	conflicts := validateConflicts(t, router)
+	if len(conflicts) != 2 {
+		t.Fatalf("expected 2 conflicts, got %d: %v", len(conflicts), conflicts)
+	}
+	if conflicts[0].Kind != navaros.ShadowedRoute {
+		t.Errorf("expected shadowed conflict, got %s", conflicts[0].Kind)
+	}
+	if conflicts[0].Pattern.String() != "/users/me" || conflicts[0].ConflictingPattern.String() != "/users/:id" {
+		t.Errorf("unexpected conflict: %s", conflicts[0])
+	}
+	if !strings.Contains(conflicts[0].CallSite, "route_conflict_test.go:") {
+		t.Errorf("expected call site in this file, got %s", conflicts[0].CallSite)
+	}
+	if conflicts[1].Kind != navaros.ShadowedRoute || conflicts[1].Pattern.String() != "/files/:dir/:name" {
+		t.Errorf("unexpected conflict: %s", conflicts[1])
+	}
+}
+
+func TestRouterValidateDuplicateRoute(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/users/:id", func(ctx *navaros.Context) {})
+	router.Get("/users/:userId", func(ctx *navaros.Context) {})
+
+	conflicts := validateConflicts(t, router)
+	if len(conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got %d: %v", len(conflicts), conflicts)
+	}
+	if conflicts[0].Kind != navaros.DuplicateRoute {
+		t.Errorf("expected duplicate conflict, got %s", conflicts[0].Kind)
+	}
+}
+
+func TestRouterValidateAmbiguousRoute(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/users/:id/posts", func(ctx *navaros.Context) {})
+	router.Get("/:kind/me/posts", func(ctx *navaros.Context) {})
+
+	conflicts := validateConflicts(t, router)
+	if len(conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got %d: %v", len(conflicts), conflicts)
+	}
+	if conflicts[0].Kind != navaros.AmbiguousRoute {
+		t.Errorf("expected ambiguous conflict, got %s", conflicts[0].Kind)
+	}
+}
+
+func TestRouterValidateHosts(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/", func(ctx *navaros.Context) {}, navaros.WithHost("a.example.com"))
+	router.Get("/", func(ctx *navaros.Context) {}, navaros.WithHost("b.example.com"))
+	router.Get("/", func(ctx *navaros.Context) {})
+	router.Get("/", func(ctx *navaros.Context) {}, navaros.WithHost("c.example.com"))
+
+	conflicts := validateConflicts(t, router)
+	if len(conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got %d: %v", len(conflicts), conflicts)
+	}
+	if conflicts[0].Kind != navaros.ShadowedRoute || conflicts[0].Host.String() != "c.example.com" {
+		t.Errorf("unexpected conflict: %s", conflicts[0])
+	}
+}
+
+func TestRouterValidateSubRouters(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.Get("/:id", func(ctx *navaros.Context) {})
+	subRouter.Get("/new", func(ctx *navaros.Context) {})
+
+	router := navaros.NewRouter()
+	router.Use("/users", subRouter)
+
+	err := router.Validate()
+	if err == nil {
+		t.Fatal("expected a conflict in the sub router")
+	}
+	if !strings.Contains(err.Error(), "GET /new") || !strings.Contains(err.Error(), "shadowed by GET /:id") {
+		t.Errorf("unexpected error message: %s", err)
+	}
+}
+
+func TestRouterValidateVersions(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/users", func(ctx *navaros.Context) {}, navaros.WithVersion("1"))
+	router.Get("/users", func(ctx *navaros.Context) {}, navaros.WithVersion("2"))
+	router.Get("/users", func(ctx *navaros.Context) {})
+	router.Get("/users", func(ctx *navaros.Context) {}, navaros.WithVersion("3"))
+
+	conflicts := validateConflicts(t, router)
+	if len(conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got %d: %v", len(conflicts), conflicts)
+	}
+	if conflicts[0].Kind != navaros.ShadowedRoute || conflicts[0].Version != "3" {
+		t.Errorf("unexpected conflict: %s", conflicts[0])
+	}
+}
+
+func TestRouterValidateConstrainedSegments(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/a/:x<int>", func(ctx *navaros.Context) {})
+	router.Get("/a/:y<uint>", func(ctx *navaros.Context) {})
+	router.Get("/b/:x<float>", func(ctx *navaros.Context) {})
+	router.Get("/b/:y<int>", func(ctx *navaros.Context) {})
+	router.Get("/y/:a.json", func(ctx *navaros.Context) {})
+	router.Get("/y/foo.:ext", func(ctx *navaros.Context) {})
+	router.Get("/z/:a(\\d+)", func(ctx *navaros.Context) {})
+	router.Get("/z/:b(1\\d*)", func(ctx *navaros.Context) {})
+
+	conflicts := validateConflicts(t, router)
+	if len(conflicts) != 4 {
+		t.Fatalf("expected 4 conflicts, got %d: %v", len(conflicts), conflicts)
+	}
+	// Values of uint beyond the range of int still reach the second route.
+	if conflicts[0].Kind != navaros.AmbiguousRoute || conflicts[0].Pattern.String() != "/a/:y<uint>" {
+		t.Errorf("unexpected conflict: %s", conflicts[0])
+	}
+	if conflicts[1].Kind != navaros.ShadowedRoute || conflicts[1].Pattern.String() != "/b/:y<int>" {
+		t.Errorf("unexpected conflict: %s", conflicts[1])
+	}
+	if conflicts[2].Kind != navaros.AmbiguousRoute || conflicts[2].Pattern.String() != "/y/foo.:ext" {
+		t.Errorf("unexpected conflict: %s", conflicts[2])
+	}
+	if conflicts[3].Kind != navaros.AmbiguousRoute || conflicts[3].Pattern.String() != "/z/:b(1\\d*)" {
+		t.Errorf("unexpected conflict: %s", conflicts[3])
+	}
+}
+
+func TestRouterValidateDisjointConstrainedSegments(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/files/:name.json", func(ctx *navaros.Context) {})
+	router.Get("/files/:name.xml", func(ctx *navaros.Context) {})
+	router.Get("/users/:id<int>", func(ctx *navaros.Context) {})
+	router.Get("/users/:id<uuid>", func(ctx *navaros.Context) {})
+	router.Get("/v/:version(v\\d+)", func(ctx *navaros.Context) {})
+	router.Get("/v/latest", func(ctx *navaros.Context) {})
+
+	if err := router.Validate(); err != nil {
+		t.Errorf("expected no conflicts, got %v", err)
+	}
+}
+
+func TestRouterValidateCaseInsensitive(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/Users", func(ctx *navaros.Context) {})
+	router.Get("/users", func(ctx *navaros.Context) {})
+
+	if err := router.Validate(); err != nil {
+		t.Errorf("expected no conflicts when matching case-sensitively, got %v", err)
+	}
+
+	router.SetCaseInsensitive(true)
+	conflicts := validateConflicts(t, router)
+	if len(conflicts) != 1 || conflicts[0].Kind != navaros.DuplicateRoute {
+		t.Fatalf("expected a duplicate conflict, got %v", conflicts)
+	}
+}
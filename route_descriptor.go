@@ -10,21 +10,35 @@ import (
 // returns these objects, and can be used to build a api map, or pre-filter
 // requests before they are passed to the router. This is most useful for
 // libraries that wish to extend the functionality of Navaros.
+//
+// Host is nil unless the route, or the router it was mounted from, was bound
+// with a host pattern using WithHost. Likewise Version is empty unless it was
+// bound to an API version using WithVersion.
 type RouteDescriptor struct {
 	Method   HTTPMethod
+	Host     *Pattern
 	Pattern  *Pattern
+	Version  string
 	Metadata any
 }
 
 // MarshalJSON returns the JSON representation of the route descriptor.
 func (r *RouteDescriptor) MarshalJSON() ([]byte, error) {
+	host := ""
+	if r.Host != nil {
+		host = r.Host.String()
+	}
 	return json.Marshal(struct {
 		Method   HTTPMethod `json:"Method"`
+		Host     string     `json:"Host,omitempty"`
 		Pattern  string     `json:"Pattern"`
+		Version  string     `json:"Version,omitempty"`
 		Metadata any        `json:"Metadata,omitempty"`
 	}{
 		Method:   r.Method,
+		Host:     host,
 		Pattern:  r.Pattern.String(),
+		Version:  r.Version,
 		Metadata: r.Metadata,
 	})
 }
@@ -33,7 +47,9 @@ func (r *RouteDescriptor) MarshalJSON() ([]byte, error) {
 func (r *RouteDescriptor) UnmarshalJSON(data []byte) error {
 	fromJSONStruct := struct {
 		Method   HTTPMethod      `json:"Method"`
+		Host     string          `json:"Host,omitempty"`
 		Pattern  string          `json:"Pattern"`
+		Version  string          `json:"Version,omitempty"`
 		Metadata json.RawMessage `json:"Metadata,omitempty"`
 	}{}
 	if err := json.Unmarshal(data, &fromJSONStruct); err != nil {
@@ -45,8 +61,23 @@ func (r *RouteDescriptor) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	r.Method = fromJSONStruct.Method
+	var host *Pattern
+	if fromJSONStruct.Host != "" {
+		host, err = NewHostPattern(fromJSONStruct.Host)
+		if err != nil {
+			return err
+		}
+	}
+
+	method, err := HTTPMethodFromString(string(fromJSONStruct.Method))
+	if err != nil {
+		return err
+	}
+
+	r.Method = method
+	r.Host = host
 	r.Pattern = pattern
+	r.Version = normalizeAPIVersion(fromJSONStruct.Version)
 	if len(fromJSONStruct.Metadata) > 0 {
 		r.Metadata = fromJSONStruct.Metadata
 	}
@@ -55,3 +55,112 @@ func TestRouteDescriptorUnmarshalJSON(t *testing.T) {
 		t.Errorf("Expected Pattern to be /a/b/c, got %s", r.Pattern)
 	}
 }
+
+func TestRouteDescriptorJSONRoundTripWithCustomMethod(t *testing.T) {
+	pattern, err := navaros.NewPattern("/files/:name")
+	if err != nil {
+		t.Fatalf("Failed to create pattern: %s", err.Error())
+	}
+
+	original := &navaros.RouteDescriptor{
+		Method:  "PROPFIND",
+		Pattern: pattern,
+	}
+
+	bytes, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("Failed to marshal route descriptor: %s", err.Error())
+	}
+
+	r := &navaros.RouteDescriptor{}
+	if err := json.Unmarshal(bytes, r); err != nil {
+		t.Fatalf("Failed to unmarshal route descriptor: %s", err.Error())
+	}
+
+	if r.Method != "PROPFIND" {
+		t.Errorf("Expected Method to be PROPFIND, got %s", r.Method)
+	}
+	if r.Pattern.String() != "/files/:name" {
+		t.Errorf("Expected Pattern to be /files/:name, got %s", r.Pattern)
+	}
+}
+
+func TestRouteDescriptorUnmarshalJSONInvalidMethod(t *testing.T) {
+	r := &navaros.RouteDescriptor{}
+	if err := r.UnmarshalJSON([]byte(`{"Method":"NOT VALID","Pattern":"/a"}`)); err == nil {
+		t.Error("Expected an error for an invalid method")
+	}
+}
+
+func TestRouteDescriptorJSONRoundTripWithHost(t *testing.T) {
+	host, err := navaros.NewHostPattern(":tenant.example.com")
+	if err != nil {
+		t.Fatalf("Failed to create host pattern: %s", err.Error())
+	}
+	pattern, err := navaros.NewPattern("/users")
+	if err != nil {
+		t.Fatalf("Failed to create pattern: %s", err.Error())
+	}
+
+	bytes, err := json.Marshal(&navaros.RouteDescriptor{
+		Method:  navaros.Get,
+		Host:    host,
+		Pattern: pattern,
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal route descriptor: %s", err.Error())
+	}
+	if string(bytes) != `{"Method":"GET","Host":":tenant.example.com","Pattern":"/users"}` {
+		t.Errorf("Unexpected JSON: %s", bytes)
+	}
+
+	r := &navaros.RouteDescriptor{}
+	if err := json.Unmarshal(bytes, r); err != nil {
+		t.Fatalf("Failed to unmarshal route descriptor: %s", err.Error())
+	}
+	if r.Host == nil || r.Host.String() != ":tenant.example.com" {
+		t.Errorf("Expected Host to be :tenant.example.com, got %v", r.Host)
+	}
+}
+
+func TestRouteDescriptorMarshalJSONOmitsEmptyHost(t *testing.T) {
+	pattern, err := navaros.NewPattern("/users")
+	if err != nil {
+		t.Fatalf("Failed to create pattern: %s", err.Error())
+	}
+
+	bytes, err := json.Marshal(&navaros.RouteDescriptor{Method: navaros.Get, Pattern: pattern})
+	if err != nil {
+		t.Fatalf("Failed to marshal route descriptor: %s", err.Error())
+	}
+	if string(bytes) != `{"Method":"GET","Pattern":"/users"}` {
+		t.Errorf("Unexpected JSON: %s", bytes)
+	}
+}
+
+func TestRouteDescriptorJSONRoundTripWithVersion(t *testing.T) {
+	pattern, err := navaros.NewPattern("/users")
+	if err != nil {
+		t.Fatalf("Failed to create pattern: %s", err.Error())
+	}
+
+	bytes, err := json.Marshal(&navaros.RouteDescriptor{
+		Method:  navaros.Get,
+		Pattern: pattern,
+		Version: "2",
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal route descriptor: %s", err.Error())
+	}
+	if string(bytes) != `{"Method":"GET","Pattern":"/users","Version":"2"}` {
+		t.Errorf("Unexpected JSON: %s", bytes)
+	}
+
+	r := &navaros.RouteDescriptor{}
+	if err := json.Unmarshal(bytes, r); err != nil {
+		t.Fatalf("Failed to unmarshal route descriptor: %s", err.Error())
+	}
+	if r.Version != "2" {
+		t.Errorf("Expected Version to be 2, got %s", r.Version)
+	}
+}
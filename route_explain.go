@@ -0,0 +1,334 @@
+package navaros
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RouteMismatch describes why a handler node does not match a request.
+type RouteMismatch string
+
+const (
+	// PatternMismatch is reported for handler nodes whose pattern does not
+	// match the request path.
+	PatternMismatch RouteMismatch = "pattern"
+	// HostMismatch is reported for handler nodes whose pattern matches, but
+	// whose host pattern does not match the request host.
+	HostMismatch RouteMismatch = "host"
+	// VersionMismatch is reported for handler nodes whose pattern matches,
+	// but which are bound to a different API version than the one requested.
+	VersionMismatch RouteMismatch = "version"
+	// MethodMismatch is reported for handler nodes whose pattern, host, and
+	// API version match, but which are bound to a different method.
+	MethodMismatch RouteMismatch = "method"
+)
+
+// RouteTrace describes how a single handler node was evaluated against a
+// request. Params holds the parameters the node would capture, including
+// those inherited from the routes the node's router is mounted with, and is
+// only set for matching nodes. Reason is only set for rejected nodes.
+type RouteTrace struct {
+	Route  RouteInfo
+	Params RequestParams
+	Reason RouteMismatch
+}
+
+// RouteExplanation is returned by Router.Explain. It describes how the
+// router would route a request.
+//
+// Matched holds the handler nodes matching the request, and Rejected those
+// that do not, each in the order the router would try them. Handler is the
+// first matching route, or router handler with a matching route, and is the
+// one expected to respond. Matching nodes after it only run if it calls
+// ctx.Next(). If no route handles the request, Handler is nil, and Status is
+// the status the router responds with itself - 404 Not Found, or an
+// automatic 405 Method Not Allowed, OPTIONS, 406 Not Acceptable, or
+// redirect response. Allow and Location hold the headers of such responses.
+type RouteExplanation struct {
+	Method   HTTPMethod
+	Path     string
+	Host     string
+	Version  string
+	Matched  []RouteTrace
+	Rejected []RouteTrace
+	Handler  *RouteInfo
+	Status   int
+	Allow    string
+	Location string
+}
+
+// Explain reports how the router would route a request with the given method
+// and path, without running any handlers. The path may include a query
+// string. It's intended for debugging requests that are not routed as
+// expected. Use ExplainRequest to explain requests with a host, or API
+// version headers.
+func (r *Router) Explain(method HTTPMethod, path string) (*RouteExplanation, error) {
+	req, err := http.NewRequest(string(method), path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return r.ExplainRequest(req)
+}
+
+// ExplainRequest is Explain for a request. The request body is not read.
+func (r *Router) ExplainRequest(req *http.Request) (*RouteExplanation, error) {
+	ctx := newContext(nil, req)
+	defer ctx.free()
+	if ctx.Error != nil {
+		return nil, ctx.Error
+	}
+
+	explanation := &RouteExplanation{
+		Method: ctx.method,
+		Path:   ctx.path,
+		Host:   ctx.host,
+	}
+
+	ctx.matchFlags = r.pathMatchFlags()
+	if r.normalizePath(ctx) {
+		explanation.setResponse(ctx)
+		return explanation, nil
+	}
+	r.prepareContext(ctx, ctx.routerSnapshot(r))
+	r.explainPreparedContext(explanation, ctx)
+
+	return explanation, nil
+}
+
+// explainPreparedContext completes an explanation for a context which has
+// had its path normalized and its API version set. The context's response
+// is set as the router would set it if no route handles the request.
+func (r *Router) explainPreparedContext(explanation *RouteExplanation, ctx *Context) {
+	explanation.Version = ctx.version
+	r.explainHandlerNodes(explanation, ctx, ctx.path, nil, routeMount{}, map[*Router]bool{})
+	if explanation.Handler == nil {
+		r.setUnhandledResponse(ctx)
+		explanation.setResponse(ctx)
+	}
+}
+
+// setExplainHeader adds the router's explain header to the response of a
+// request it is serving. The explanation is of the path the request is
+// routed with, after normalization, using the same route snapshots. If
+// redirected is true the path policy has already answered the request with a
+// redirect, and only that is reported.
+func (r *Router) setExplainHeader(ctx *Context, redirected bool) {
+	explanation := &RouteExplanation{
+		Method: ctx.method,
+		Path:   ctx.path,
+		Host:   ctx.host,
+	}
+	if redirected {
+		explanation.setResponse(ctx)
+	} else {
+		// The explanation's unhandled response is set on a sub context so
+		// it doesn't become the response of the request itself.
+		explainCtx := newSubContext(ctx)
+		r.explainPreparedContext(explanation, explainCtx)
+		explainCtx.free()
+	}
+	ctx.Headers.Set(r.explainHeader, explanation.Summary())
+}
+
+// explainHandlerNodes evaluates the router's handler nodes against the
+// context with the given path, as mounted at mount, adding them to the
+// explanation. Matching nodes inherit parentParams. True is returned if one
+// of the router's routes would handle the request.
+func (r *Router) explainHandlerNodes(explanation *RouteExplanation, ctx *Context, path string, parentParams RequestParams, mount routeMount, explaining map[*Router]bool) bool {
+	explaining[r] = true
+	defer delete(explaining, r)
+
+	handled := false
+	for _, handlerNode := range ctx.routerSnapshot(r).handlerNodes {
+		route := newRouteInfo(handlerNode, mount)
+		if route.Kind == WrapRoute {
+			explanation.Matched = append(explanation.Matched, RouteTrace{Route: route})
+			continue
+		}
+
+		params, reason := explainHandlerNode(handlerNode, ctx, path)
+		if reason != "" {
+			explanation.Rejected = append(explanation.Rejected, RouteTrace{Route: route, Reason: reason})
+			continue
+		}
+		for key, value := range parentParams {
+			if _, ok := params[key]; !ok {
+				params[key] = value
+			}
+		}
+		explanation.Matched = append(explanation.Matched, RouteTrace{Route: route, Params: params})
+
+		routeHandled := route.Kind == EndpointRoute
+		if route.Kind == MountRoute {
+			subPath, ok := handlerNode.Pattern.mountSubPath(path, ctx.matchFlags)
+			if !ok {
+				subPath = path
+			}
+			subMount := mount.sub(handlerNode, route)
+			for _, handlerOrTransformer := range handlerNode.HandlersAndTransformers {
+				if router, ok := handlerOrTransformer.(*Router); ok {
+					if !explaining[router] && router.explainHandlerNodes(explanation, ctx, subPath, params, subMount, explaining) {
+						routeHandled = true
+					}
+				} else if routerHandler, ok := handlerOrTransformer.(RouterHandler); ok && routerHandlerMatches(routerHandler, ctx, subPath) {
+					routeHandled = true
+				}
+			}
+		}
+		if routeHandled {
+			handled = true
+			if explanation.Handler == nil {
+				explanation.Handler = &route
+			}
+		}
+	}
+
+	return handled
+}
+
+// explainHandlerNode matches a handler node against the context with the
+// given path as tryMatch does, returning the parameters it captures, or why
+// it does not match.
+func explainHandlerNode(handlerNode *HandlerNode, ctx *Context, path string) (RequestParams, RouteMismatch) {
+	params := RequestParams{}
+	if !handlerNode.Pattern.matchInto(path, params, nil, ctx.matchFlags) {
+		return nil, PatternMismatch
+	}
+	if handlerNode.Host != nil && !handlerNode.Host.matchesPath(ctx.host, 0) {
+		return nil, HostMismatch
+	}
+	if !ctx.acceptsAPIVersion(handlerNode.Version) {
+		return nil, VersionMismatch
+	}
+	if handlerNode.Method != All && handlerNode.Method != ctx.method && (handlerNode.Method != Get || !ctx.headMatchesGet) {
+		return nil, MethodMismatch
+	}
+	if handlerNode.Host != nil {
+		handlerNode.Host.addMatchedParams(ctx.host, params)
+	}
+	return params, ""
+}
+
+// routerHandlerMatches reports whether one of a router handler's route
+// descriptors matches the context with the given path.
+func routerHandlerMatches(routerHandler RouterHandler, ctx *Context, path string) bool {
+	for _, routeDescriptor := range routerHandler.RouteDescriptors() {
+		if routeDescriptor.Method != All && routeDescriptor.Method != ctx.method && (routeDescriptor.Method != Get || !ctx.headMatchesGet) {
+			continue
+		}
+		if routeDescriptor.Host != nil && !routeDescriptor.Host.matchesPath(ctx.host, 0) {
+			continue
+		}
+		if !ctx.acceptsAPIVersion(routeDescriptor.Version) {
+			continue
+		}
+		if routeDescriptor.Pattern.matchesPath(path, ctx.matchFlags) {
+			return true
+		}
+	}
+	return false
+}
+
+// setResponse records the response the router set on the context for a
+// request no route handled.
+func (e *RouteExplanation) setResponse(ctx *Context) {
+	e.Status = ctx.Status
+	if e.Status == 0 {
+		e.Status = http.StatusNotFound
+	}
+	e.Allow = ctx.Headers.Get("Allow")
+	if redirect, ok := ctx.Body.(*Redirect); ok {
+		e.Location = redirect.To
+	}
+}
+
+// Outcome returns a short description of how the request would be answered.
+func (e *RouteExplanation) Outcome() string {
+	if e.Handler != nil {
+		return "handled by " + describeRouteInfo(*e.Handler)
+	}
+	outcome := strconv.Itoa(e.Status) + " " + http.StatusText(e.Status)
+	if e.Location != "" {
+		outcome += " to " + e.Location
+	}
+	if e.Allow != "" {
+		outcome += " (Allow: " + e.Allow + ")"
+	}
+	return outcome
+}
+
+// Summary returns a single line description of the explanation, suitable for
+// a header or log line. It includes the outcome, the matching handler nodes,
+// and the rejected handler nodes whose pattern matched.
+func (e *RouteExplanation) Summary() string {
+	builder := strings.Builder{}
+	builder.WriteString(e.Outcome())
+	if len(e.Matched) != 0 {
+		builder.WriteString("; matched: ")
+		for i, trace := range e.Matched {
+			if i != 0 {
+				builder.WriteString(", ")
+			}
+			builder.WriteString(describeRouteInfo(trace.Route))
+		}
+	}
+	isFirst := true
+	for _, trace := range e.Rejected {
+		if trace.Reason == PatternMismatch {
+			continue
+		}
+		if isFirst {
+			builder.WriteString("; rejected: ")
+			isFirst = false
+		} else {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(describeRouteInfo(trace.Route) + " (" + string(trace.Reason) + ")")
+	}
+	return builder.String()
+}
+
+// String returns a multi-line description of the explanation, including
+// every handler node evaluated and the parameters captured by those which
+// match.
+func (e *RouteExplanation) String() string {
+	builder := strings.Builder{}
+	builder.WriteString(string(e.Method) + " " + e.Path)
+	if e.Host != "" {
+		builder.WriteString(" on host " + e.Host)
+	}
+	if e.Version != "" {
+		builder.WriteString(" for version " + e.Version)
+	}
+	builder.WriteString(": " + e.Outcome() + "\n")
+	for _, trace := range e.Matched {
+		builder.WriteString("  matched " + describeRouteInfo(trace.Route))
+		if len(trace.Params) != 0 {
+			builder.WriteString(" with")
+			for _, key := range slices.Sorted(maps.Keys(trace.Params)) {
+				builder.WriteString(" " + key + "=" + trace.Params[key])
+			}
+		}
+		builder.WriteString("\n")
+	}
+	for _, trace := range e.Rejected {
+		builder.WriteString("  rejected " + describeRouteInfo(trace.Route) + " by " + string(trace.Reason) + "\n")
+	}
+	return builder.String()
+}
+
+// describeRouteInfo returns a short description of a route, such as
+// "GET /users/:id" or "ALL /** (middleware)".
+func describeRouteInfo(route RouteInfo) string {
+	if route.Kind == WrapRoute {
+		return "wrap"
+	}
+	description := string(route.Method) + " " + route.Pattern.String()
+	if route.Kind != EndpointRoute {
+		description += " (" + string(route.Kind) + ")"
+	}
+	return description
+}
@@ -0,0 +1,174 @@
+package navaros_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RobertWHurst/navaros"
+)
+
+func TestRouterExplain(t *testing.T) {
+	handled := false
+	subRouter := navaros.NewRouter()
+	subRouter.Get("/users/:id", func(ctx *navaros.Context) { ctx.Body = "user" })
+	subRouter.Delete("/users/:id", func(ctx *navaros.Context) {})
+
+	router := navaros.NewRouter()
+	router.Use(func(ctx *navaros.Context) { ctx.Next() })
+	router.Post("/login", func(ctx *navaros.Context) {})
+	router.Use("/orgs/:org", subRouter)
+	router.Get("/orgs/:org/users/:id", func(ctx *navaros.Context) { handled = true })
+
+	explanation, err := router.Explain(navaros.Get, "/orgs/acme/users/1?full=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if handled {
+		t.Error("expected no handlers to run")
+	}
+
+	if explanation.Handler == nil || explanation.Handler.Pattern.String() != "/orgs/:org/users/:id" || explanation.Handler.Depth != 1 {
+		t.Fatalf("expected the sub router's route to handle the request, got %+v", explanation.Handler)
+	}
+	if explanation.Outcome() != "handled by GET /orgs/:org/users/:id" {
+		t.Errorf("unexpected outcome %q", explanation.Outcome())
+	}
+
+	var matched []string
+	for _, trace := range explanation.Matched {
+		matched = append(matched, string(trace.Route.Kind)+" "+trace.Route.Pattern.String())
+	}
+	expected := "middleware /**, mount /orgs/:org/**, route /orgs/:org/users/:id, route /orgs/:org/users/:id"
+	if strings.Join(matched, ", ") != expected {
+		t.Errorf("expected matches %s, got %s", expected, strings.Join(matched, ", "))
+	}
+	params := explanation.Matched[2].Params
+	if params.Get("org") != "acme" || params.Get("id") != "1" {
+		t.Errorf("expected the mount's and route's params, got %v", params)
+	}
+
+	reasons := map[string]navaros.RouteMismatch{}
+	for _, trace := range explanation.Rejected {
+		reasons[string(trace.Route.Method)+" "+trace.Route.Pattern.String()] = trace.Reason
+	}
+	if reasons["POST /login"] != navaros.PatternMismatch || reasons["DELETE /orgs/:org/users/:id"] != navaros.MethodMismatch {
+		t.Errorf("unexpected rejections %v", reasons)
+	}
+}
+
+func TestRouterExplainUnhandled(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.Get("/users/:id", func(ctx *navaros.Context) {})
+	subRouter.Delete("/users/:id", func(ctx *navaros.Context) {})
+
+	router := navaros.NewRouter()
+	router.Post("/login", func(ctx *navaros.Context) {})
+	router.Use("/orgs/:org", subRouter)
+	router.SetTrailingSlashPolicy(navaros.RedirectPath)
+
+	cases := []struct {
+		method   navaros.HTTPMethod
+		path     string
+		status   int
+		allow    string
+		location string
+	}{
+		{navaros.Get, "/missing", http.StatusNotFound, "", ""},
+		{navaros.Put, "/orgs/acme/users/1", http.StatusMethodNotAllowed, "GET, DELETE, HEAD, OPTIONS", ""},
+		{navaros.Options, "/login", http.StatusNoContent, "POST, OPTIONS", ""},
+		{navaros.Get, "/orgs/acme/users/1/", http.StatusMovedPermanently, "", "/orgs/acme/users/1"},
+	}
+	for _, c := range cases {
+		explanation, err := router.Explain(c.method, c.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if explanation.Handler != nil || explanation.Status != c.status || explanation.Allow != c.allow || explanation.Location != c.location {
+			t.Errorf("%s %s: expected %d %q %q, got %s", c.method, c.path, c.status, c.allow, c.location, explanation.Outcome())
+		}
+	}
+
+	if _, err := router.Explain("NOT VALID", "/"); err == nil {
+		t.Error("expected an error for an invalid method")
+	}
+}
+
+func TestRouterExplainHostAndVersion(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/status", func(ctx *navaros.Context) {}, navaros.WithHost(":tenant.example.com"))
+	router.Get("/status", func(ctx *navaros.Context) {}, navaros.WithVersion("2"))
+
+	req := httptest.NewRequest("GET", "/status", nil)
+	req.Host = "other.test"
+	req.Header.Set("Api-Version", "1")
+	explanation, err := router.ExplainRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(explanation.Rejected) != 2 || explanation.Rejected[0].Reason != navaros.HostMismatch || explanation.Rejected[1].Reason != navaros.VersionMismatch {
+		t.Errorf("expected host and version rejections, got %+v", explanation.Rejected)
+	}
+	if explanation.Status != http.StatusNotAcceptable {
+		t.Errorf("expected 406, got %d", explanation.Status)
+	}
+
+	req.Host = "acme.example.com"
+	explanation, err = router.ExplainRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if explanation.Handler == nil || explanation.Matched[0].Params.Get("tenant") != "acme" {
+		t.Errorf("expected the host route to match with its params, got %s", explanation)
+	}
+}
+
+func TestRouterExplainHeader(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.Get("/users/:id", func(ctx *navaros.Context) {})
+	subRouter.Delete("/users/:id", func(ctx *navaros.Context) {})
+
+	router := navaros.NewRouter()
+	router.Use(func(ctx *navaros.Context) { ctx.Next() })
+	router.Use("/orgs/:org", subRouter)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("PUT", "/orgs/acme/users/1", nil))
+	if res.Header().Get("X-Route-Explain") != "" {
+		t.Error("expected no explain header by default")
+	}
+
+	router.SetExplainHeader("X-Route-Explain")
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("PUT", "/orgs/acme/users/1", nil))
+	expected := "405 Method Not Allowed (Allow: GET, DELETE, HEAD, OPTIONS); matched: ALL /** (middleware), ALL /orgs/:org/** (mount); " +
+		"rejected: GET /orgs/:org/users/:id (method), DELETE /orgs/:org/users/:id (method)"
+	if res.Header().Get("X-Route-Explain") != expected {
+		t.Errorf("expected %q, got %q", expected, res.Header().Get("X-Route-Explain"))
+	}
+}
+
+func TestRouterExplainHeaderDescribesNormalizedPath(t *testing.T) {
+	router := navaros.NewRouter()
+	router.SetCleanPathPolicy(navaros.LenientPath)
+	router.SetExplainHeader("X-Route-Explain")
+	router.Get("/users/:id", func(ctx *navaros.Context) {
+		ctx.Body = ctx.Params().Get("id")
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/admin/../users/1", nil))
+	expected := "handled by GET /users/:id; matched: GET /users/:id"
+	if res.Body.String() != "1" || res.Header().Get("X-Route-Explain") != expected {
+		t.Errorf("expected 1 with %q, got %s with %q", expected, res.Body.String(), res.Header().Get("X-Route-Explain"))
+	}
+
+	router.SetCleanPathPolicy(navaros.RedirectPath)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/admin/../users/1", nil))
+	expected = "301 Moved Permanently to /users/1"
+	if res.Code != http.StatusMovedPermanently || res.Header().Get("X-Route-Explain") != expected {
+		t.Errorf("expected 301 with %q, got %d with %q", expected, res.Code, res.Header().Get("X-Route-Explain"))
+	}
+}
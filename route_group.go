@@ -0,0 +1,102 @@
+package navaros
+
+import (
+	"slices"
+	"strings"
+)
+
+// routeGroup holds the state of a router created by Group. Such routers do
+// not have handler chains of their own. Everything bound on them is passed to
+// the router the group belongs to, with the group's prefix, middleware, wrap
+// handlers, and default route options applied.
+type routeGroup struct {
+	parent       *Router
+	prefix       string
+	options      []any
+	middleware   []any
+	wrapHandlers []HandlerFunc
+}
+
+// Group creates a group of routes sharing a path prefix, middleware, wrap
+// handlers, and default route options. fn is called with a router for the
+// group, and the routes bound on it are added to this router with the prefix
+// prepended to their paths. Middleware added with Use and wrap handlers added
+// with Wrap only apply to the routes of the group bound after them, and run as
+// part of each of those routes that matches a request. The route options
+// given to Group apply to every route in the group unless the route overrides
+// them. Route names must be unique, so Group panics if given WithName; name
+// the group's routes individually instead.
+//
+// Unlike mounting a router with Use, a group does not create a sub context
+// for each request, as its routes belong to this router. Routers mounted
+// within a group with Use are mounted under the group's prefix, and run after
+// the group's middleware. Router settings such as SetAutoHead, and
+// MethodNotAllowed handlers, have no effect on the group's router, and should
+// be configured on this router instead.
+func (r *Router) Group(prefix string, fn func(g *Router), options ...RouteOption) {
+	if fn == nil {
+		panic("no group function provided")
+	}
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		panic("group prefix must start with a leading slash")
+	}
+
+	group := &routeGroup{
+		parent: r,
+		prefix: strings.TrimSuffix(prefix, "/"),
+	}
+	for _, option := range options {
+		if _, ok := option.(NameOption); ok {
+			panic("route names must be unique, so WithName cannot be given to Group - name the group's routes individually instead")
+		}
+		group.options = append(group.options, option)
+	}
+	fn(&Router{group: group})
+}
+
+// bindAt binds a route of the group on the group's parent router.
+func (g *routeGroup) bindAt(callSite string, isPublic bool, method HTTPMethod, path string, wrapHandlers []HandlerFunc, handlersAndTransformers []any) {
+	path, wrapHandlers, handlersAndTransformers = g.apply(path, wrapHandlers, handlersAndTransformers)
+	g.parent.bindAt(callSite, isPublic, method, path, wrapHandlers, handlersAndTransformers...)
+}
+
+// apply applies the group's prefix, wrap handlers, middleware, and default
+// route options to a route of the group.
+func (g *routeGroup) apply(path string, wrapHandlers []HandlerFunc, handlersAndTransformers []any) (string, []HandlerFunc, []any) {
+	groupHandlersAndTransformers := make([]any, 0, len(g.options)+len(g.middleware)+len(handlersAndTransformers))
+	groupHandlersAndTransformers = append(groupHandlersAndTransformers, g.options...)
+	groupHandlersAndTransformers = append(groupHandlersAndTransformers, g.middleware...)
+	groupHandlersAndTransformers = append(groupHandlersAndTransformers, handlersAndTransformers...)
+
+	groupPath := g.prefix + path
+	if groupPath == "" {
+		groupPath = "/"
+	} else if g.prefix != "" && path == "/" {
+		groupPath = g.prefix
+	}
+
+	groupWrapHandlers := append(slices.Clone(g.wrapHandlers), wrapHandlers...)
+
+	return groupPath, groupWrapHandlers, groupHandlersAndTransformers
+}
+
+// use adds handlers and transformers passed to Use on the group's router to
+// the group's middleware. False is returned, and nothing is added, if they
+// include routers or route options, in which case they should be bound as a
+// mount instead.
+func (g *routeGroup) use(handlersAndTransformers []any) bool {
+	if len(handlersAndTransformers) == 0 {
+		panic("no handlers or transformers provided")
+	}
+	for _, handlerOrTransformer := range handlersAndTransformers {
+		if _, ok := handlerOrTransformer.(RouterHandler); ok {
+			return false
+		}
+		if _, ok := handlerOrTransformer.(RouteOption); ok {
+			return false
+		}
+	}
+	checkHandlersAndTransformers(handlersAndTransformers)
+	g.middleware = append(g.middleware, handlersAndTransformers...)
+	return true
+}
@@ -23,3 +23,62 @@ func (MetadataOption) isRouteOption() {}
 func WithMetadata(value any) MetadataOption {
 	return MetadataOption{value: value}
 }
+
+// NameOption is a RouteOption that gives a route a name, so that its URL can
+// be built with the router's URLFor method.
+type NameOption struct {
+	name string
+}
+
+func (NameOption) isRouteOption() {}
+
+// WithName creates a RouteOption that names a route. The name can be passed
+// to URLFor on the router, or any router it is mounted in, to build the
+// route's URL. Names must be unique within a router.
+func WithName(name string) NameOption {
+	return NameOption{name: name}
+}
+
+// HostOption is a RouteOption that restricts a route to requests whose host
+// matches a host pattern.
+type HostOption struct {
+	pattern *Pattern
+}
+
+func (HostOption) isRouteOption() {}
+
+// WithHost creates a RouteOption that restricts a route, or a mounted router,
+// to requests whose host matches the given host pattern. Host patterns use
+// the same syntax as route patterns with dots separating their segments, so
+// ":tenant.api.example.com" matches any subdomain of api.example.com and
+// captures it as the tenant parameter. Captured host parameters are available
+// through ctx.Params() alongside those of the path. The port of the request's
+// host is ignored. WithHost panics if the host pattern is invalid.
+func WithHost(hostPattern string) HostOption {
+	pattern, err := NewHostPattern(hostPattern)
+	if err != nil {
+		panic(err)
+	}
+	return HostOption{pattern: pattern}
+}
+
+// VersionOption is a RouteOption that binds a route to an API version.
+type VersionOption struct {
+	version string
+}
+
+func (VersionOption) isRouteOption() {}
+
+// WithVersion creates a RouteOption that binds a route, or a mounted router,
+// to an API version. Requests select a version with the Api-Version header,
+// or with the Accept header using a vendor media type such as
+// application/vnd.acme.v2+json, or a version parameter such as
+// application/json; version=2. A leading v is ignored, so "v2" and "2" are
+// the same version. Routes without a version match requests for any version.
+// WithVersion panics if the version is empty.
+func WithVersion(version string) VersionOption {
+	if version == "" {
+		panic("version cannot be empty")
+	}
+	return VersionOption{version: normalizeAPIVersion(version)}
+}
@@ -0,0 +1,108 @@
+package navaros
+
+import (
+	"slices"
+	"strings"
+)
+
+// routeTree indexes a router's handler nodes by the static segments at the
+// start of their patterns. Looking up a path yields only the handler nodes
+// that could possibly match it, in registration order, so the context does
+// not need to try every node in the router's chain.
+type routeTree struct {
+	root routeTreeNode
+}
+
+// routeTreeNode is a single static path segment within a route tree.
+type routeTreeNode struct {
+	children map[string]*routeTreeNode
+
+	// handlerNodes have a static prefix ending at this tree node. Their
+	// patterns may still match paths that continue beyond it.
+	handlerNodes []*HandlerNode
+
+	// exactHandlerNodes have entirely static patterns ending at this tree
+	// node, and so can only match paths that also end here.
+	exactHandlerNodes []*HandlerNode
+}
+
+// insert adds a handler node to the tree. Nodes must be inserted in the order
+// they are registered in the router, and have their seq set.
+func (t *routeTree) insert(handlerNode *HandlerNode) {
+	treeNode := &t.root
+	isExact := handlerNode.Pattern != nil
+	if handlerNode.Pattern != nil {
+		for _, currentChunk := range handlerNode.Pattern.chunks {
+			if currentChunk.kind != static || currentChunk.modifier != single || currentChunk.regExp != nil {
+				isExact = false
+				break
+			}
+			if treeNode.children == nil {
+				treeNode.children = map[string]*routeTreeNode{}
+			}
+			key := strings.ToLower(currentChunk.literal)
+			childTreeNode, ok := treeNode.children[key]
+			if !ok {
+				childTreeNode = &routeTreeNode{}
+				treeNode.children[key] = childTreeNode
+			}
+			treeNode = childTreeNode
+		}
+	}
+
+	if isExact {
+		treeNode.exactHandlerNodes = append(treeNode.exactHandlerNodes, handlerNode)
+	} else {
+		treeNode.handlerNodes = append(treeNode.handlerNodes, handlerNode)
+	}
+}
+
+// appendHandlerNodes appends the handler nodes which may match path to
+// handlerNodes, in registration order, and returns the extended slice.
+func (t *routeTree) appendHandlerNodes(handlerNodes []*HandlerNode, path string) []*HandlerNode {
+	start := len(handlerNodes)
+	sourceCount := 0
+
+	path = strings.TrimSuffix(path, "/")
+	treeNode := &t.root
+	for {
+		if len(treeNode.handlerNodes) != 0 {
+			handlerNodes = append(handlerNodes, treeNode.handlerNodes...)
+			sourceCount += 1
+		}
+		if path == "" {
+			if len(treeNode.exactHandlerNodes) != 0 {
+				handlerNodes = append(handlerNodes, treeNode.exactHandlerNodes...)
+				sourceCount += 1
+			}
+			break
+		}
+		if path[0] != '/' {
+			break
+		}
+
+		segment, rest := path[1:], ""
+		if i := strings.IndexByte(segment, '/'); i != -1 {
+			segment, rest = segment[:i], segment[i:]
+		}
+		// Segments are indexed decoded and in lower case so the tree also
+		// serves routers matching paths case-insensitively. The patterns of
+		// the nodes found still decide whether the case matters.
+		childTreeNode, ok := treeNode.children[strings.ToLower(unescapePathValue(segment))]
+		if !ok {
+			break
+		}
+		treeNode = childTreeNode
+		path = rest
+	}
+
+	// Each tree node's list is already in registration order, so sorting is
+	// only needed when more than one contributed.
+	if sourceCount > 1 {
+		slices.SortFunc(handlerNodes[start:], func(a, b *HandlerNode) int {
+			return a.seq - b.seq
+		})
+	}
+
+	return handlerNodes
+}
@@ -0,0 +1,340 @@
+package navaros
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteKind describes the role a handler node plays in a router.
+type RouteKind string
+
+const (
+	// EndpointRoute is reported for routes bound with the http method
+	// binding methods, such as Get and Post, or with All on a pattern which
+	// does not end in "**".
+	EndpointRoute RouteKind = "route"
+	// MiddlewareRoute is reported for handlers bound with Use, or with All
+	// on a pattern ending in "**".
+	MiddlewareRoute RouteKind = "middleware"
+	// WrapRoute is reported for handlers registered with Wrap.
+	WrapRoute RouteKind = "wrap"
+	// MountRoute is reported for routes with a router handler, such as a
+	// router mounted with Use.
+	MountRoute RouteKind = "mount"
+)
+
+// RouteInfo describes a handler node visited by Router.Walk. Unlike route
+// descriptors, route info is reported for every handler node, including
+// middleware, wraps, and private routes.
+//
+// Pattern is the full pattern the node matches, including the paths of the
+// routers it is mounted under, and is nil for wraps. Host and Version are
+// inherited from the routes routers are mounted with, as they are for route
+// descriptors. Handlers and WrapHandlers hold the names of the node's
+// handlers: the fully qualified name for functions, and the type name for
+// other handlers and transformers. Depth is the number of routers the node
+// is nested within, and CallSite is the file and line the node was bound at,
+// if known.
+type RouteInfo struct {
+	Kind         RouteKind
+	Method       HTTPMethod
+	Host         *Pattern
+	Pattern      *Pattern
+	Version      string
+	Name         string
+	Public       bool
+	Handlers     []string
+	WrapHandlers []string
+	Metadata     any
+	Depth        int
+	CallSite     string
+
+	// localPattern is the pattern the node was bound with on its own router.
+	// It is used by the tree format.
+	localPattern string
+}
+
+// MarshalJSON returns the JSON representation of the route info.
+func (i RouteInfo) MarshalJSON() ([]byte, error) {
+	host := ""
+	if i.Host != nil {
+		host = i.Host.String()
+	}
+	pattern := ""
+	if i.Pattern != nil {
+		pattern = i.Pattern.String()
+	}
+	return json.Marshal(struct {
+		Kind         RouteKind  `json:"Kind"`
+		Method       HTTPMethod `json:"Method"`
+		Host         string     `json:"Host,omitempty"`
+		Pattern      string     `json:"Pattern,omitempty"`
+		Version      string     `json:"Version,omitempty"`
+		Name         string     `json:"Name,omitempty"`
+		Public       bool       `json:"Public"`
+		Handlers     []string   `json:"Handlers,omitempty"`
+		WrapHandlers []string   `json:"WrapHandlers,omitempty"`
+		Metadata     any        `json:"Metadata,omitempty"`
+		Depth        int        `json:"Depth"`
+		CallSite     string     `json:"CallSite,omitempty"`
+	}{
+		Kind:         i.Kind,
+		Method:       i.Method,
+		Host:         host,
+		Pattern:      pattern,
+		Version:      i.Version,
+		Name:         i.Name,
+		Public:       i.Public,
+		Handlers:     i.Handlers,
+		WrapHandlers: i.WrapHandlers,
+		Metadata:     i.Metadata,
+		Depth:        i.Depth,
+		CallSite:     i.CallSite,
+	})
+}
+
+// Walk calls fn for every handler node of the router, in the order they
+// were bound. When a router is mounted on this one, fn is called for the
+// mount, then for each of the mounted router's handler nodes. Router handlers
+// which are not navaros routers, such as service clients, are only reported
+// as mounts. Walking stops at the first error returned by fn, and the error
+// is returned.
+//
+// Walk reports the routes current when it is called, and is safe to call
+// while the router is serving traffic. It's intended for debugging, and for
+// logging the route table at startup.
+func (r *Router) Walk(fn func(route RouteInfo) error) error {
+	return r.walk(fn, routeMount{}, map[*Router]bool{})
+}
+
+// routeMount holds what a mounted router's routes inherit from the route it
+// was mounted with.
+type routeMount struct {
+	prefix  string
+	host    *Pattern
+	version string
+	depth   int
+}
+
+// sub returns the mount of the routers mounted by a handler node, given the
+// node's route info.
+func (m routeMount) sub(handlerNode *HandlerNode, route RouteInfo) routeMount {
+	subMount := routeMount{
+		host:    route.Host,
+		version: route.Version,
+		depth:   m.depth + 1,
+	}
+	if handlerNode.Pattern.endsWithMountWildcard() {
+		subMount.prefix = strings.TrimSuffix(route.Pattern.String(), "/**")
+	}
+	return subMount
+}
+
+// walk calls fn for the router's handler nodes, as mounted at mount.
+// walking holds the routers being walked, so routers mounted within
+// themselves are not walked forever.
+func (r *Router) walk(fn func(route RouteInfo) error, mount routeMount, walking map[*Router]bool) error {
+	walking[r] = true
+	defer delete(walking, r)
+
+	for _, handlerNode := range r.currentSnapshot().handlerNodes {
+		route := newRouteInfo(handlerNode, mount)
+		if err := fn(route); err != nil {
+			return err
+		}
+		if route.Kind != MountRoute {
+			continue
+		}
+		subMount := mount.sub(handlerNode, route)
+		for _, handlerOrTransformer := range handlerNode.HandlersAndTransformers {
+			if router, ok := handlerOrTransformer.(*Router); ok && !walking[router] {
+				if err := router.walk(fn, subMount, walking); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// newRouteInfo creates the route info for a handler node mounted at mount.
+func newRouteInfo(handlerNode *HandlerNode, mount routeMount) RouteInfo {
+	route := RouteInfo{
+		Kind:         EndpointRoute,
+		Method:       handlerNode.Method,
+		Host:         handlerNode.Host,
+		Version:      handlerNode.Version,
+		Name:         handlerNode.Name,
+		Public:       handlerNode.isPublic,
+		Handlers:     make([]string, 0, len(handlerNode.HandlersAndTransformers)),
+		WrapHandlers: make([]string, 0, len(handlerNode.WrapHandlers)),
+		Metadata:     handlerNode.metadata,
+		Depth:        mount.depth,
+		CallSite:     handlerNode.callSite,
+	}
+	if route.Host == nil {
+		route.Host = mount.host
+	}
+	if route.Version == "" {
+		route.Version = mount.version
+	}
+	for _, wrapHandler := range handlerNode.WrapHandlers {
+		route.WrapHandlers = append(route.WrapHandlers, handlerName(wrapHandler))
+	}
+
+	if handlerNode.Pattern == nil {
+		route.Kind = WrapRoute
+		return route
+	}
+
+	route.localPattern = handlerNode.Pattern.String()
+	route.Pattern = handlerNode.Pattern
+	if mount.prefix != "" {
+		pattern, err := NewPattern(mount.prefix + route.localPattern)
+		if err != nil {
+			panic(err)
+		}
+		route.Pattern = pattern
+	}
+
+	if handlerNode.Method == All && handlerNode.Pattern.endsWithMountWildcard() {
+		route.Kind = MiddlewareRoute
+	}
+	for _, handlerOrTransformer := range handlerNode.HandlersAndTransformers {
+		if _, ok := handlerOrTransformer.(RouterHandler); ok {
+			route.Kind = MountRoute
+		}
+		route.Handlers = append(route.Handlers, handlerName(handlerOrTransformer))
+	}
+
+	return route
+}
+
+// handlerName returns the name of a handler or transformer. Functions are
+// named after the function, resolved with runtime.FuncForPC, and other values
+// after their type.
+func handlerName(handler any) string {
+	value := reflect.ValueOf(handler)
+	if value.Kind() == reflect.Func && !value.IsNil() {
+		if fn := runtime.FuncForPC(value.Pointer()); fn != nil {
+			return fn.Name()
+		}
+	}
+	return reflect.TypeOf(handler).String()
+}
+
+// RouteFormat selects how WriteRoutes renders a router's routes.
+type RouteFormat string
+
+const (
+	// RouteTableFormat renders routes as a table with a row per handler
+	// node, and full patterns.
+	RouteTableFormat RouteFormat = "table"
+	// RouteJSONFormat renders routes as a JSON array of route info objects.
+	RouteJSONFormat RouteFormat = "json"
+	// RouteTreeFormat renders routes as a tree, with the routes of mounted
+	// routers indented below their mount, and patterns relative to it.
+	RouteTreeFormat RouteFormat = "tree"
+)
+
+// WriteRoutes renders every handler node of the router, as reported by
+// Walk, to w in the given format. It's intended for printing the route table
+// at startup, or while debugging.
+func (r *Router) WriteRoutes(w io.Writer, format RouteFormat) error {
+	var routes []RouteInfo
+	if err := r.Walk(func(route RouteInfo) error {
+		routes = append(routes, route)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	switch format {
+	case RouteTableFormat:
+		return writeRouteTable(w, routes)
+	case RouteJSONFormat:
+		if routes == nil {
+			routes = []RouteInfo{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(routes)
+	case RouteTreeFormat:
+		return writeRouteTree(w, routes)
+	default:
+		return errors.New("unknown route format `" + string(format) + "`")
+	}
+}
+
+// writeRouteTable writes routes as an aligned table.
+func writeRouteTable(w io.Writer, routes []RouteInfo) error {
+	tableWriter := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tableWriter, "KIND\tMETHOD\tHOST\tPATTERN\tVERSION\tNAME\tHANDLERS")
+	for _, route := range routes {
+		host := "-"
+		if route.Host != nil {
+			host = route.Host.String()
+		}
+		pattern := "-"
+		if route.Pattern != nil {
+			pattern = route.Pattern.String()
+		}
+		fmt.Fprintln(tableWriter, strings.Join([]string{
+			string(route.Kind),
+			string(route.Method),
+			host,
+			pattern,
+			orDash(route.Version),
+			orDash(route.Name),
+			orDash(strings.Join(slices.Concat(route.WrapHandlers, route.Handlers), ", ")),
+		}, "\t"))
+	}
+	return tableWriter.Flush()
+}
+
+// writeRouteTree writes routes as an indented tree.
+func writeRouteTree(w io.Writer, routes []RouteInfo) error {
+	builder := strings.Builder{}
+	for _, route := range routes {
+		builder.WriteString(strings.Repeat("  ", route.Depth))
+		if route.Kind == WrapRoute {
+			builder.WriteString("wrap")
+		} else {
+			builder.WriteString(string(route.Method) + " " + route.localPattern)
+			if route.Kind != EndpointRoute {
+				builder.WriteString(" (" + string(route.Kind) + ")")
+			}
+		}
+		if route.Host != nil {
+			builder.WriteString(" host=" + route.Host.String())
+		}
+		if route.Version != "" {
+			builder.WriteString(" version=" + route.Version)
+		}
+		if route.Name != "" {
+			builder.WriteString(" name=" + route.Name)
+		}
+		if handlers := slices.Concat(route.WrapHandlers, route.Handlers); len(handlers) != 0 {
+			builder.WriteString(" -> " + strings.Join(handlers, ", "))
+		}
+		builder.WriteString("\n")
+	}
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+// orDash returns value, or "-" if it is empty.
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,197 @@
+package navaros_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/RobertWHurst/navaros"
+)
+
+func walkTestMiddleware(ctx *navaros.Context) { ctx.Next() }
+
+func walkTestHandler(ctx *navaros.Context) {}
+
+func TestRouterWalk(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.PublicGet("/users/:id", walkTestHandler, navaros.WithName("user"), navaros.WithMetadata("meta"))
+
+	router := navaros.NewRouter()
+	router.Use(walkTestMiddleware)
+	router.Wrap(walkTestMiddleware)
+	router.Post("/login", walkTestHandler)
+	router.Use("/api", subRouter, navaros.WithVersion("v1"))
+
+	var routes []navaros.RouteInfo
+	err := router.Walk(func(route navaros.RouteInfo) error {
+		routes = append(routes, route)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		kind    navaros.RouteKind
+		method  navaros.HTTPMethod
+		pattern string
+		version string
+		depth   int
+	}{
+		{navaros.MiddlewareRoute, navaros.All, "/**", "", 0},
+		{navaros.WrapRoute, navaros.All, "", "", 0},
+		{navaros.EndpointRoute, navaros.Post, "/login", "", 0},
+		{navaros.MountRoute, navaros.All, "/api/**", "1", 0},
+		{navaros.EndpointRoute, navaros.Get, "/api/users/:id", "1", 1},
+	}
+	if len(routes) != len(expected) {
+		t.Fatalf("expected %d routes, got %d", len(expected), len(routes))
+	}
+	for i, e := range expected {
+		route := routes[i]
+		pattern := ""
+		if route.Pattern != nil {
+			pattern = route.Pattern.String()
+		}
+		if route.Kind != e.kind || route.Method != e.method || pattern != e.pattern || route.Version != e.version || route.Depth != e.depth {
+			t.Errorf("route %d: expected %s %s %s %s %d, got %s %s %s %s %d", i,
+				e.kind, e.method, e.pattern, e.version, e.depth,
+				route.Kind, route.Method, pattern, route.Version, route.Depth)
+		}
+	}
+
+	if len(routes[0].Handlers) != 1 || routes[0].Handlers[0] != "github.com/RobertWHurst/navaros_test.walkTestMiddleware" {
+		t.Errorf("expected the middleware's function name, got %v", routes[0].Handlers)
+	}
+	if len(routes[1].WrapHandlers) != 1 || !strings.HasSuffix(routes[1].WrapHandlers[0], "walkTestMiddleware") {
+		t.Errorf("expected the wrap handler's function name, got %v", routes[1].WrapHandlers)
+	}
+	if routes[3].Handlers[0] != "*navaros.Router" {
+		t.Errorf("expected the mount's handler to be named after its type, got %v", routes[3].Handlers)
+	}
+	user := routes[4]
+	if user.Name != "user" || !user.Public || user.Metadata != "meta" || user.CallSite == "" {
+		t.Errorf("expected the user route's details, got %+v", user)
+	}
+	if routes[2].Public {
+		t.Error("expected the login route to be private")
+	}
+}
+
+func TestRouterWalkStopsOnError(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.PublicGet("/users/:id", walkTestHandler, navaros.WithName("user"), navaros.WithMetadata("meta"))
+
+	router := navaros.NewRouter()
+	router.Use(walkTestMiddleware)
+	router.Wrap(walkTestMiddleware)
+	router.Post("/login", walkTestHandler)
+	router.Use("/api", subRouter, navaros.WithVersion("v1"))
+
+	stop := errors.New("stop")
+	count := 0
+	err := router.Walk(func(route navaros.RouteInfo) error {
+		count += 1
+		if route.Kind == navaros.MountRoute {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || count != 4 {
+		t.Errorf("expected walking to stop at the mount, got %v after %d routes", err, count)
+	}
+}
+
+func TestRouterWriteRoutesTable(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.PublicGet("/users/:id", walkTestHandler, navaros.WithName("user"), navaros.WithMetadata("meta"))
+
+	router := navaros.NewRouter()
+	router.Use(walkTestMiddleware)
+	router.Wrap(walkTestMiddleware)
+	router.Post("/login", walkTestHandler)
+	router.Use("/api", subRouter, navaros.WithVersion("v1"))
+
+	buf := bytes.Buffer{}
+	if err := router.WriteRoutes(&buf, navaros.RouteTableFormat); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("expected a header and 5 rows, got:\n%s", buf.String())
+	}
+	if fields := strings.Fields(lines[0]); strings.Join(fields, " ") != "KIND METHOD HOST PATTERN VERSION NAME HANDLERS" {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	fields := strings.Fields(lines[5])
+	if strings.Join(fields[:6], " ") != "route GET - /api/users/:id 1 user" || !strings.HasSuffix(fields[6], ".walkTestHandler") {
+		t.Errorf("unexpected row %q", lines[5])
+	}
+}
+
+func TestRouterWriteRoutesJSON(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.PublicGet("/users/:id", walkTestHandler, navaros.WithName("user"), navaros.WithMetadata("meta"))
+
+	router := navaros.NewRouter()
+	router.Use(walkTestMiddleware)
+	router.Wrap(walkTestMiddleware)
+	router.Post("/login", walkTestHandler)
+	router.Use("/api", subRouter, navaros.WithVersion("v1"))
+
+	buf := bytes.Buffer{}
+	if err := router.WriteRoutes(&buf, navaros.RouteJSONFormat); err != nil {
+		t.Fatal(err)
+	}
+	var routes []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &routes); err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 5 {
+		t.Fatalf("expected 5 routes, got %d", len(routes))
+	}
+	user := routes[4]
+	if user["Kind"] != "route" || user["Pattern"] != "/api/users/:id" || user["Name"] != "user" || user["Metadata"] != "meta" || user["Depth"] != float64(1) {
+		t.Errorf("unexpected route %v", user)
+	}
+	if _, ok := routes[1]["Pattern"]; ok {
+		t.Errorf("expected the wrap to have no pattern, got %v", routes[1])
+	}
+}
+
+func TestRouterWriteRoutesTree(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.PublicGet("/users/:id", walkTestHandler, navaros.WithName("user"), navaros.WithMetadata("meta"))
+
+	router := navaros.NewRouter()
+	router.Use(walkTestMiddleware)
+	router.Wrap(walkTestMiddleware)
+	router.Post("/login", walkTestHandler)
+	router.Use("/api", subRouter, navaros.WithVersion("v1"))
+
+	buf := bytes.Buffer{}
+	if err := router.WriteRoutes(&buf, navaros.RouteTreeFormat); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected 5 lines, got:\n%s", buf.String())
+	}
+	if !strings.HasPrefix(lines[3], "ALL /api/** (mount) version=1 -> *navaros.Router") {
+		t.Errorf("unexpected mount line %q", lines[3])
+	}
+	if !strings.HasPrefix(lines[4], "  GET /users/:id version=1 name=user -> ") {
+		t.Errorf("expected the sub route to be indented with its own pattern, got %q", lines[4])
+	}
+	if !strings.HasPrefix(lines[1], "wrap -> ") {
+		t.Errorf("unexpected wrap line %q", lines[1])
+	}
+}
+
+func TestRouterWriteRoutesUnknownFormat(t *testing.T) {
+	if err := navaros.NewRouter().WriteRoutes(&bytes.Buffer{}, "yaml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -1,14 +1,31 @@
 package navaros
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
+// PrintHandlerErrors has no effect.
+//
+// Deprecated: Handler errors are always reported to the router's logger. Use
+// Router.SetLogger or SetDefaultLogger to choose where they go, or a logger
+// with a higher level to silence them.
 var PrintHandlerErrors = false
 
-// SetPrintHandlerErrors toggles the printing of handler errors.
+// SetPrintHandlerErrors has no effect.
+//
+// Deprecated: Handler errors are always reported to the router's logger. Use
+// Router.SetLogger or SetDefaultLogger to choose where they go, or a logger
+// with a higher level to silence them.
 func SetPrintHandlerErrors(enable bool) {
 	PrintHandlerErrors = enable
 }
@@ -19,10 +36,27 @@ func SetPrintHandlerErrors(enable bool) {
 // standard http servers. It also implements Navaros' own Handler interface,
 // which allows nesting routers for better code organization.
 type Router struct {
-	routeDescriptorMap map[HTTPMethod]map[string]bool
-	routeDescriptors   []*RouteDescriptor
-	firstHandlerNode   *HandlerNode
-	lastHandlerNode    *HandlerNode
+	// mu guards handlerNodes and nextSeq. Requests never take it; they use
+	// the router's snapshot instead.
+	mu           sync.Mutex
+	handlerNodes []*HandlerNode
+	nextSeq      int
+	snapshot     atomic.Pointer[routerSnapshot]
+	group        *routeGroup
+
+	disableAutoMethodNotAllowed bool
+	disableAutoOptions          bool
+	disableAutoHead             bool
+	methodNotAllowedHandlerNode *HandlerNode
+	notFoundHandlerNode         *HandlerNode
+	errorHandler                func(ctx *Context, err error)
+	logger                      *slog.Logger
+	defaultAPIVersion           string
+	caseInsensitive             bool
+	caseInsensitiveParams       bool
+	cleanPathPolicy             PathPolicy
+	trailingSlashPolicy         PathPolicy
+	explainHeader               string
 }
 
 // NewRouter creates a new router.
@@ -34,18 +68,87 @@ func NewRouter() *Router {
 // servers. It handles the incoming request - creating a context and running
 // the handler chain over it, then finalizing the response.
 func (r *Router) ServeHTTP(res http.ResponseWriter, req *http.Request) {
-	ctx := NewContextWithNode(res, req, r.firstHandlerNode)
+	ctx := newContext(res, req)
+	ctx.matchFlags = r.pathMatchFlags()
+	ctx.foldParamCase = r.caseInsensitiveParams
+	ctx.errorHandler = r.errorHandler
+	ctx.logger = r.logger
+	if r.normalizePath(ctx) {
+		if r.explainHeader != "" {
+			r.setExplainHeader(ctx, true)
+		}
+		ctx.finalize()
+		ctx.free()
+		return
+	}
+	snapshot := ctx.routerSnapshot(r)
+	ctx.handlerNodes = snapshot.routeTree.appendHandlerNodes(ctx.handlerNodes, ctx.path)
+	ctx.beginHandlerNodes()
+	r.prepareContext(ctx, snapshot)
+	if r.explainHeader != "" {
+		r.setExplainHeader(ctx, false)
+	}
 	ctx.Next()
+	if ctx.isUnhandled() {
+		r.respondToUnhandled(ctx)
+	}
+	ctx.handleError()
 	ctx.finalize()
 	ctx.free()
 }
 
+// prepareContext sets the API version a request is routed with, and whether
+// HEAD requests fall back to GET routes.
+func (r *Router) prepareContext(ctx *Context, snapshot *routerSnapshot) {
+	if snapshot.usesAPIVersions(ctx) {
+		hasAPIVersion := func(version string) bool {
+			return snapshot.hasAPIVersion(ctx, version)
+		}
+		ctx.version = requestAPIVersion(ctx.request, hasAPIVersion)
+		if r.defaultAPIVersion != "" && (ctx.version == "" || !hasAPIVersion(ctx.version)) {
+			ctx.version = r.defaultAPIVersion
+		}
+	}
+	if ctx.method == Head && !r.disableAutoHead {
+		ctx.headMatchesGet = !slices.Contains(r.appendMatchingMethods(nil, ctx, ctx.path), Head)
+	}
+}
+
 // Handle is for the purpose of taking an existing context, and running it
 // through the mux's handler chain. If the last handler calls next, it
-// will call next on the original context.
+// will call next on the original context. If the router was mounted with a
+// path, its handlers are matched against the remainder of the path beyond
+// the mount path.
 func (r *Router) Handle(ctx *Context) {
-	subCtx := NewSubContextWithNode(ctx, r.firstHandlerNode)
+	subCtx := newSubContext(ctx)
+	if r.errorHandler != nil {
+		subCtx.errorHandler = r.errorHandler
+	}
+	if r.logger != nil {
+		subCtx.logger = r.logger
+	}
+	if ctx.matchedPattern != nil {
+		if subPath, ok := ctx.matchedPattern.mountSubPath(ctx.path, ctx.matchFlags); ok {
+			subCtx.path = subPath
+			subCtx.mountPrefix += mountPathPrefix(ctx.matchedPattern)
+		}
+	}
+	subCtx.handlerNodes = subCtx.routerSnapshot(r).routeTree.appendHandlerNodes(subCtx.handlerNodes, subCtx.path)
+	subCtx.beginHandlerNodes()
 	subCtx.Next()
+	if subCtx.nextBeyondEnd && r.notFoundHandlerNode != nil && subCtx.isUnhandled() && !r.matchesOtherRoutes(subCtx) {
+		subCtx.Status = http.StatusNotFound
+		subCtx.runHandlerNode(r.notFoundHandlerNode)
+	}
+	if r.errorHandler != nil {
+		subCtx.handleError()
+	}
+	if r.logger != nil {
+		subCtx.logHandlerError()
+	}
+	if r.errorHandler != nil || r.logger != nil {
+		subCtx.tryUpdateParent()
+	}
 	nextBeyondEnd := subCtx.nextBeyondEnd
 	subCtx.free()
 	if nextBeyondEnd {
@@ -53,10 +156,160 @@ func (r *Router) Handle(ctx *Context) {
 	}
 }
 
+// SetAutoMethodNotAllowed toggles automatic 405 Method Not Allowed
+// responses. When enabled, which is the default, a request that no handler
+// responds to, but which matches routes bound to other methods, is answered
+// with a 405 and an Allow header listing the methods those routes accept.
+// Routes within sub-routers are included. When disabled such requests
+// receive a 404 as before.
+func (r *Router) SetAutoMethodNotAllowed(enable bool) {
+	r.disableAutoMethodNotAllowed = !enable
+}
+
+// SetAutoOptions toggles automatic responses to OPTIONS requests. When
+// enabled, which is the default, an OPTIONS request that no handler responds
+// to is answered with a 204 and an Allow header listing the methods bound to
+// the request path, including those of routes within sub-routers. Explicitly
+// bound OPTIONS handlers still take precedence. OPTIONS is also included in
+// the Allow header of 405 responses while this is enabled.
+func (r *Router) SetAutoOptions(enable bool) {
+	r.disableAutoOptions = !enable
+}
+
+// SetAutoHead toggles implicit handling of HEAD requests. When enabled, which
+// is the default, HEAD requests to paths without any HEAD routes are handled
+// by the path's GET handlers instead. The response body is dropped, but the
+// status, headers, and a Content-Length computed from the body are kept. HEAD
+// is also included in Allow headers wherever GET is while this is enabled.
+func (r *Router) SetAutoHead(enable bool) {
+	r.disableAutoHead = !enable
+}
+
+// SetDefaultVersion sets the API version used for requests that do not
+// request a version, or request one that none of the router's routes are
+// bound to. Without a default, requests for unknown versions receive a 406
+// Not Acceptable if the only routes matching their path are bound to other
+// versions, and requests without a version are matched by routes of any
+// version, in registration order. The default is only used by the router
+// serving requests, not by routers mounted on it.
+func (r *Router) SetDefaultVersion(version string) {
+	r.defaultAPIVersion = normalizeAPIVersion(version)
+}
+
+// SetCleanPathPolicy sets how request paths with repeated slashes, or "." and
+// ".." segments, are handled. With StrictPath, the default, such paths are
+// matched as they are. With LenientPath they are cleaned before matching, so
+// "//users/../admin" matches "/admin", and with RedirectPath the request is
+// redirected to the clean path. Trailing slashes are kept by cleaning, and
+// are handled by the trailing slash policy. Path settings are taken from the
+// router serving requests, and also apply to the routers mounted on it.
+func (r *Router) SetCleanPathPolicy(policy PathPolicy) {
+	r.cleanPathPolicy = policy
+}
+
+// SetTrailingSlashPolicy sets how trailing slashes on request paths are
+// handled. With LenientPath, the default, a trailing slash is ignored, so
+// "/users/" matches "/users" and "/users" matches "/users/". With StrictPath
+// routes only match paths which have a trailing slash if their pattern has
+// one. With RedirectPath matching is strict, but a request no route handles
+// is redirected to the path with the trailing slash added or removed if a
+// route for its method matches that path. Patterns ending with a repeating
+// segment, such as the paths routers are mounted at, accept paths with or
+// without a trailing slash under every policy.
+func (r *Router) SetTrailingSlashPolicy(policy PathPolicy) {
+	r.trailingSlashPolicy = policy
+}
+
+// SetCaseInsensitive toggles case-insensitive matching of the static
+// segments of route patterns. It is disabled by default. Parameter values
+// keep the case they have in the request path, and custom sub-patterns are
+// not affected.
+func (r *Router) SetCaseInsensitive(enable bool) {
+	r.caseInsensitive = enable
+}
+
+// SetCaseInsensitiveParams toggles case-insensitive lookup of route
+// parameters with ctx.Param. It is disabled by default, so "/users/:userId"
+// must be read as ctx.Param("userId"). When enabled, keys which don't match
+// a parameter exactly are compared case-insensitively, so ctx.Param("userid")
+// works too, at the cost of a slower lookup for such keys.
+func (r *Router) SetCaseInsensitiveParams(enable bool) {
+	r.caseInsensitiveParams = enable
+}
+
+// SetExplainHeader sets the name of a response header the router adds to
+// every response, summarizing how the request was routed as reported by
+// Explain. The summary describes the path the request is routed with, once
+// the router's path policies have cleaned it, or the redirect they respond
+// with. It is disabled by default, or when set to an empty string. It's
+// intended for development only, as explaining each request is slow, and the
+// header exposes the router's routes to clients.
+func (r *Router) SetExplainHeader(name string) {
+	r.explainHeader = name
+}
+
+// NotFound registers handlers that are run when no route responds to a
+// request, in place of the router's empty 404 Not Found response. The status
+// is already set to 404 when they are called, so typically they only need to
+// set the body, which is encoded by whatever marshaller the request's
+// middleware set up. Requests answered with an automatic 405 Method Not
+// Allowed, OPTIONS, 406 Not Acceptable, or redirect response are not
+// considered not found.
+//
+// NotFound handlers on a mounted router run for requests that reach the end
+// of its handlers without a response, if none of its routes match the
+// request path for another method or API version. Such requests don't
+// continue on to the handlers after the router, so a router can render its
+// own not found responses for the paths it's mounted at.
+func (r *Router) NotFound(handlersAndTransformers ...any) {
+	if len(handlersAndTransformers) == 0 {
+		panic("no handlers or transformers provided")
+	}
+	checkHandlersAndTransformers(handlersAndTransformers)
+	r.notFoundHandlerNode = &HandlerNode{
+		Method:                  All,
+		HandlersAndTransformers: handlersAndTransformers,
+	}
+}
+
+// OnError registers a function that is called when a handler sets
+// ctx.Error, or panics, in place of the router's empty 500 Internal Server
+// Error response. The status is already set to 500, and the body cleared,
+// when it is called, so it can render the error however suits the
+// application, such as with a JSON body encoded by the marshaller set up by
+// the request's middleware. ctx.Error is left set, so it's still reported in
+// ctx.FinalError once the response is finalized. If the response has
+// already been started, the function is not called.
+//
+// Error functions are scoped to the router they are registered on. Errors
+// from handlers within a mounted router are passed to its own error
+// function if it has one, and otherwise to that of the router it is mounted
+// on.
+func (r *Router) OnError(errorHandler func(ctx *Context, err error)) {
+	r.errorHandler = errorHandler
+}
+
+// MethodNotAllowed registers handlers that are run when the router responds
+// with 405 Method Not Allowed. The status and Allow header are already set
+// when they are called, so typically they only need to set the body, though
+// they may change any part of the response.
+func (r *Router) MethodNotAllowed(handlersAndTransformers ...any) {
+	if len(handlersAndTransformers) == 0 {
+		panic("no handlers or transformers provided")
+	}
+	checkHandlersAndTransformers(handlersAndTransformers)
+	r.methodNotAllowedHandlerNode = &HandlerNode{
+		Method:                  All,
+		HandlersAndTransformers: handlersAndTransformers,
+	}
+}
+
 // RouteDescriptors returns a list of all the route descriptors that this
-// router is responsible for. Useful for gateway configuration.
+// router is responsible for. Useful for gateway configuration. The list is
+// cached until the router's routes change, and shared between callers, so it
+// must not be modified.
 func (r *Router) RouteDescriptors() []*RouteDescriptor {
-	return r.routeDescriptors
+	return r.currentSnapshot().routeDescriptors()
 }
 
 // Wrap registers handlers that will be called around every subsequent handler
@@ -80,17 +333,15 @@ func (r *Router) Wrap(handlers ...any) {
 		}
 	}
 
-	node := &HandlerNode{
+	if r.group != nil {
+		r.group.wrapHandlers = append(r.group.wrapHandlers, wrapHandlers...)
+		return
+	}
+
+	r.appendHandlerNode(&HandlerNode{
 		Method:       All,
 		WrapHandlers: wrapHandlers,
-	}
-	if r.firstHandlerNode == nil {
-		r.firstHandlerNode = node
-		r.lastHandlerNode = node
-	} else {
-		r.lastHandlerNode.Next = node
-		r.lastHandlerNode = node
-	}
+	})
 }
 
 // Use is for middleware handlers. It allows the handlers to be executed on
@@ -105,6 +356,7 @@ func (r *Router) Wrap(handlers ...any) {
 // only be executed on requests with a path of "/foo/bar".
 func (r *Router) Use(handlersAndTransformers ...any) {
 	mountPath := "/**"
+	hasCustomMountPath := false
 	if len(handlersAndTransformers) != 0 {
 		if customMountPath, ok := handlersAndTransformers[0].(string); ok {
 			if !strings.HasSuffix(customMountPath, "/**") {
@@ -112,10 +364,15 @@ func (r *Router) Use(handlersAndTransformers ...any) {
 				customMountPath += "/**"
 			}
 			mountPath = customMountPath
+			hasCustomMountPath = true
 			handlersAndTransformers = handlersAndTransformers[1:]
 		}
 	}
 
+	if r.group != nil && !hasCustomMountPath && r.group.use(handlersAndTransformers) {
+		return
+	}
+
 	r.bind(false, All, mountPath, handlersAndTransformers...)
 }
 
@@ -167,6 +424,14 @@ func (r *Router) Head(path string, handlersAndTransformers ...any) {
 	r.bind(false, Head, path, handlersAndTransformers...)
 }
 
+// Method allows binding handlers to any HTTP method at a given route path
+// pattern. This is useful for methods without a dedicated binding method,
+// such as CONNECT, TRACE, QUERY, or extension methods like WebDAV's
+// PROPFIND. The method must be a valid HTTP token.
+func (r *Router) Method(method HTTPMethod, path string, handlersAndTransformers ...any) {
+	r.bind(false, mustHTTPMethod(method), path, handlersAndTransformers...)
+}
+
 // PublicAll is the same as All, but it also adds the route descriptor to the
 // router's list of public route descriptors.
 func (r *Router) PublicAll(path string, handlersAndTransformers ...any) {
@@ -215,14 +480,115 @@ func (r *Router) PublicHead(path string, handlersAndTransformers ...any) {
 	r.bind(true, Head, path, handlersAndTransformers...)
 }
 
+// PublicMethod is the same as Method, but it also adds the route descriptor
+// to the router's list of public route descriptors.
+func (r *Router) PublicMethod(method HTTPMethod, path string, handlersAndTransformers ...any) {
+	r.bind(true, mustHTTPMethod(method), path, handlersAndTransformers...)
+}
+
+// Remove removes the route given a name with WithName from the router. It
+// returns false if the router has no route with the name.
+//
+// Routes may be added, removed, and replaced while the router is serving
+// requests. Requests already in flight continue with the routes they started
+// with, and see none of the change.
+func (r *Router) Remove(name string) bool {
+	if r.group != nil {
+		return r.group.parent.Remove(name)
+	}
+	return r.removeHandlerNode(name, nil)
+}
+
+// Replace replaces the route given a name with WithName with a new route, in
+// the same position in the router. The new route keeps the name unless it is
+// given a different one with WithName, and is included in the router's route
+// descriptors if the route it replaces was public. It returns false, and
+// binds nothing, if the router has no route with the name.
+//
+// Like Remove, Replace is safe to call while the router is serving requests.
+func (r *Router) Replace(name string, method HTTPMethod, path string, handlersAndTransformers ...any) bool {
+	callSite := ""
+	if _, file, line, ok := runtime.Caller(1); ok {
+		callSite = file + ":" + strconv.Itoa(line)
+	}
+	return r.replaceAt(callSite, name, mustHTTPMethod(method), path, nil, handlersAndTransformers)
+}
+
+// replaceAt is Replace for a route registered at the given call site. Routes
+// replaced on a group are replaced on the router the group belongs to, with
+// the group's prefix, middleware, and options applied.
+func (r *Router) replaceAt(callSite string, name string, method HTTPMethod, path string, wrapHandlers []HandlerFunc, handlersAndTransformers []any) bool {
+	if r.group != nil {
+		path, wrapHandlers, handlersAndTransformers = r.group.apply(path, wrapHandlers, handlersAndTransformers)
+		return r.group.parent.replaceAt(callSite, name, method, path, wrapHandlers, handlersAndTransformers)
+	}
+	return r.removeHandlerNode(name, newRouteHandlerNode(callSite, false, method, path, wrapHandlers, handlersAndTransformers))
+}
+
+// URLFor builds the URL of the route given a name with WithName. Parameter
+// values are taken from params and percent-encoded, and query is appended as
+// a query string if it is not empty. Routes within routers mounted on this
+// one with Use are found as well, and the path the router is mounted at is
+// included in the URL. An error is returned if no route has the name, or if
+// a required parameter is missing from params.
+func (r *Router) URLFor(name string, params RequestParams, query url.Values) (string, error) {
+	path, found, err := r.pathFor(name, params)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", errors.New("no route named `" + name + "`")
+	}
+	if len(query) != 0 {
+		path += "?" + query.Encode()
+	}
+	return path, nil
+}
+
+// pathFor builds the path of the named route, searching the router's own
+// routes first, then those of its mounted routers in order. found is false if
+// no route has the name.
+func (r *Router) pathFor(name string, params RequestParams) (path string, found bool, err error) {
+	snapshot := r.currentSnapshot()
+	if handlerNode, ok := snapshot.namedHandlerNodes[name]; ok {
+		path, err := handlerNode.Pattern.buildPath(handlerNode.Pattern.chunks, params, nil, true)
+		return path, true, err
+	}
+
+	for _, handlerNode := range snapshot.handlerNodes {
+		for _, handlerOrTransformer := range handlerNode.HandlersAndTransformers {
+			router, ok := handlerOrTransformer.(*Router)
+			if !ok {
+				continue
+			}
+			subPath, found, err := router.pathFor(name, params)
+			if !found {
+				continue
+			}
+			if err != nil {
+				return "", true, err
+			}
+			prefix, err := handlerNode.Pattern.mountPrefixPath(params)
+			if err != nil {
+				return "", true, err
+			}
+			if prefix != "" && subPath == "/" {
+				return prefix, true, nil
+			}
+			return prefix + subPath, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
 // Lookup takes a handler or transformer and looks up what HTTP method and
 // route pattern it is bound to. Returns the method, pattern, and true if found,
 // or empty string, nil, and false if not found.
 func (r *Router) Lookup(handlerOrTransformer any) (HTTPMethod, *Pattern, bool) {
 	targetPtr := reflect.ValueOf(handlerOrTransformer).Pointer()
 
-	currentNode := r.firstHandlerNode
-	for currentNode != nil {
+	for _, currentNode := range r.currentSnapshot().handlerNodes {
 		for _, h := range currentNode.HandlersAndTransformers {
 			if reflect.ValueOf(h).Pointer() == targetPtr {
 				return currentNode.Method, currentNode.Pattern, true
@@ -233,7 +599,6 @@ func (r *Router) Lookup(handlerOrTransformer any) (HTTPMethod, *Pattern, bool) {
 				}
 			}
 		}
-		currentNode = currentNode.Next
 	}
 
 	return "", nil, false
@@ -241,8 +606,31 @@ func (r *Router) Lookup(handlerOrTransformer any) (HTTPMethod, *Pattern, bool) {
 
 // bind creates a pattern object from the route pattern as well as a handler
 // node. It then attaches the new link to the end of the router's handler
-// chain.
+// chain. bind must be called directly by the router's public binding methods,
+// as it records the location they were called from for Validate.
 func (r *Router) bind(isPublic bool, method HTTPMethod, path string, handlersAndTransformers ...any) {
+	callSite := ""
+	if _, file, line, ok := runtime.Caller(2); ok {
+		callSite = file + ":" + strconv.Itoa(line)
+	}
+	r.bindAt(callSite, isPublic, method, path, nil, handlersAndTransformers...)
+}
+
+// bindAt is bind for a route registered at the given call site, with wrap
+// handlers that apply only to the route's own handlers. Routes bound on a
+// group are passed to the router the group belongs to.
+func (r *Router) bindAt(callSite string, isPublic bool, method HTTPMethod, path string, wrapHandlers []HandlerFunc, handlersAndTransformers ...any) {
+	if r.group != nil {
+		r.group.bindAt(callSite, isPublic, method, path, wrapHandlers, handlersAndTransformers)
+		return
+	}
+	r.appendHandlerNode(newRouteHandlerNode(callSite, isPublic, method, path, wrapHandlers, handlersAndTransformers))
+}
+
+// newRouteHandlerNode creates the handler node for a route, extracting any
+// route options from handlersAndTransformers. It panics if the path or the
+// handlers are invalid.
+func newRouteHandlerNode(callSite string, isPublic bool, method HTTPMethod, path string, wrapHandlers []HandlerFunc, handlersAndTransformers []any) *HandlerNode {
 	if len(handlersAndTransformers) == 0 {
 		panic("no handlers or transformers provided")
 	}
@@ -254,11 +642,24 @@ func (r *Router) bind(isPublic bool, method HTTPMethod, path string, handlersAnd
 
 	// Extract route options before handler validation.
 	var metadata any
+	var host *Pattern
+	var name string
+	var version string
+	var timeout *TimeoutOption
 	filtered := make([]any, 0, len(handlersAndTransformers))
 	for _, item := range handlersAndTransformers {
 		if opt, ok := item.(RouteOption); ok {
-			if m, ok := opt.(MetadataOption); ok {
-				metadata = m.value
+			switch o := opt.(type) {
+			case MetadataOption:
+				metadata = o.value
+			case HostOption:
+				host = o.pattern
+			case NameOption:
+				name = o.name
+			case VersionOption:
+				version = o.version
+			case TimeoutOption:
+				timeout = &o
 			}
 			continue
 		}
@@ -270,73 +671,207 @@ func (r *Router) bind(isPublic bool, method HTTPMethod, path string, handlersAnd
 		panic("no handlers or transformers provided")
 	}
 
-	for _, handlerOrTransformer := range handlersAndTransformers {
-		if _, ok := handlerOrTransformer.(Transformer); ok {
-			continue
-		} else if _, ok := handlerOrTransformer.(Handler); ok {
+	checkHandlersAndTransformers(handlersAndTransformers)
+
+	if timeout != nil {
+		handlersAndTransformers = append([]any{Timeout(timeout.timeout, timeout.options)}, handlersAndTransformers...)
+	}
+
+	return &HandlerNode{
+		Method:                  method,
+		Pattern:                 pattern,
+		Host:                    host,
+		Name:                    name,
+		Version:                 version,
+		HandlersAndTransformers: handlersAndTransformers,
+		WrapHandlers:            wrapHandlers,
+		isPublic:                isPublic,
+		metadata:                metadata,
+		callSite:                callSite,
+	}
+}
+
+// respondToUnhandled is called once the handler chain has finished without
+// any handler responding. If routes match the request path it answers
+// OPTIONS requests with the methods they accept, or responds with 405 Method
+// Not Allowed if none of them accept the request method. If the only routes
+// matching the path are for other API versions, it responds with 406 Not
+// Acceptable. Otherwise the router's NotFound handlers are run, if it has
+// any.
+func (r *Router) respondToUnhandled(ctx *Context) {
+	r.setUnhandledResponse(ctx)
+	if ctx.Status == http.StatusMethodNotAllowed && r.methodNotAllowedHandlerNode != nil {
+		ctx.runHandlerNode(r.methodNotAllowedHandlerNode)
+	}
+	if ctx.isUnhandled() && r.notFoundHandlerNode != nil {
+		ctx.Status = http.StatusNotFound
+		ctx.runHandlerNode(r.notFoundHandlerNode)
+	}
+}
+
+// matchesOtherRoutes reports whether any of the router's routes match the
+// context's path for another method or API version. Requests which do are
+// left for the router they are being handled by to answer with a 405 Method
+// Not Allowed, OPTIONS, or 406 Not Acceptable response.
+func (r *Router) matchesOtherRoutes(ctx *Context) bool {
+	if ctx.version != "" && r.onlyMatchesOtherAPIVersions(ctx) {
+		return true
+	}
+	return len(r.appendMatchingMethods(nil, ctx, ctx.path)) != 0
+}
+
+// setUnhandledResponse sets the response for a request no route handled,
+// without running any handlers. The status is left unset if the request
+// should be answered with a 404.
+func (r *Router) setUnhandledResponse(ctx *Context) {
+	if ctx.version != "" && r.onlyMatchesOtherAPIVersions(ctx) {
+		ctx.Status = http.StatusNotAcceptable
+		return
+	}
+	if r.redirectTrailingSlash(ctx) {
+		return
+	}
+	if r.disableAutoMethodNotAllowed && r.disableAutoOptions {
+		return
+	}
+
+	methods := r.appendMatchingMethods(nil, ctx, ctx.path)
+	if len(methods) == 0 {
+		return
+	}
+	if !r.disableAutoHead && slices.Contains(methods, Get) {
+		methods = appendMethod(methods, Head)
+	}
+	if !r.disableAutoOptions {
+		methods = appendMethod(methods, Options)
+		if ctx.method == Options {
+			ctx.Status = http.StatusNoContent
+			ctx.Headers.Set("Allow", joinMethods(methods))
+			return
+		}
+	}
+
+	if r.disableAutoMethodNotAllowed || slices.Contains(methods, ctx.method) {
+		return
+	}
+
+	ctx.Status = http.StatusMethodNotAllowed
+	ctx.Headers.Set("Allow", joinMethods(methods))
+}
+
+// appendMatchingMethods appends the methods of the routes that match the
+// context's host and API version, and path, to methods, in registration order
+// and without duplicates.
+// Sub-routers are searched using the path relative to where they are mounted.
+// Handlers bound to all methods are skipped as they cannot be told apart from
+// middleware.
+func (r *Router) appendMatchingMethods(methods []HTTPMethod, ctx *Context, path string) []HTTPMethod {
+	for _, handlerNode := range ctx.routerSnapshot(r).routeTree.appendHandlerNodes(nil, path) {
+		if handlerNode.Pattern == nil || !handlerNode.Pattern.matchesPath(path, ctx.matchFlags) {
 			continue
-		} else if _, ok := handlerOrTransformer.(HandlerFunc); ok {
+		}
+		if handlerNode.Host != nil && !handlerNode.Host.matchesPath(ctx.host, 0) {
 			continue
-		} else if _, ok := handlerOrTransformer.(func(*Context)); ok {
+		}
+		if !ctx.acceptsAPIVersion(handlerNode.Version) {
 			continue
 		}
 
-		panic("invalid handler type. Must be a Transformer, Handler, or " +
-			"HandlerFunc. Got: " + reflect.TypeOf(handlerOrTransformer).String())
-	}
-
-	hasAddedOwnRouteDescriptor := false
-	for _, handlerOrTransformer := range handlersAndTransformers {
-		if routerHandler, ok := handlerOrTransformer.(RouterHandler); ok {
-			for _, routeDescriptor := range routerHandler.RouteDescriptors() {
-				mountPath := strings.TrimSuffix(path, "/**")
-				subPattern, err := NewPattern(mountPath + routeDescriptor.Pattern.String())
-				if err != nil {
-					panic(err)
+		subPath, ok := handlerNode.Pattern.mountSubPath(path, ctx.matchFlags)
+		if !ok {
+			subPath = path
+		}
+		for _, handlerOrTransformer := range handlerNode.HandlersAndTransformers {
+			var subMethods []HTTPMethod
+			if router, ok := handlerOrTransformer.(*Router); ok {
+				subMethods = router.appendMatchingMethods(nil, ctx, subPath)
+			} else if routerHandler, ok := handlerOrTransformer.(RouterHandler); ok {
+				for _, routeDescriptor := range routerHandler.RouteDescriptors() {
+					if routeDescriptor.Host != nil && !routeDescriptor.Host.matchesPath(ctx.host, 0) {
+						continue
+					}
+					if !ctx.acceptsAPIVersion(routeDescriptor.Version) {
+						continue
+					}
+					if routeDescriptor.Pattern.matchesPath(subPath, ctx.matchFlags) {
+						subMethods = appendMethod(subMethods, routeDescriptor.Method)
+					}
+				}
+			}
+			for _, method := range subMethods {
+				if handlerNode.Method == All || handlerNode.Method == method {
+					methods = appendMethod(methods, method)
 				}
-				r.addRouteDescriptor(routeDescriptor.Method, subPattern, routeDescriptor.Metadata)
 			}
-		} else if isPublic && !hasAddedOwnRouteDescriptor {
-			r.addRouteDescriptor(method, pattern, metadata)
-			hasAddedOwnRouteDescriptor = true
 		}
-	}
 
-	nextHandlerNode := &HandlerNode{
-		Method:                  method,
-		Pattern:                 pattern,
-		HandlersAndTransformers: handlersAndTransformers,
+		if handlerNode.Method != All {
+			methods = appendMethod(methods, handlerNode.Method)
+		}
 	}
+	return methods
+}
 
-	if r.firstHandlerNode == nil {
-		r.firstHandlerNode = nextHandlerNode
-		r.lastHandlerNode = nextHandlerNode
-	} else {
-		r.lastHandlerNode.Next = nextHandlerNode
-		r.lastHandlerNode = nextHandlerNode
+// onlyMatchesOtherAPIVersions reports whether the routes matching the
+// context's path are all bound to API versions other than the one requested.
+func (r *Router) onlyMatchesOtherAPIVersions(ctx *Context) bool {
+	if len(r.appendMatchingMethods(nil, ctx, ctx.path)) != 0 {
+		return false
 	}
+	version := ctx.version
+	ctx.version = ""
+	methods := r.appendMatchingMethods(nil, ctx, ctx.path)
+	ctx.version = version
+	return len(methods) != 0
 }
 
-// addRouteDescriptor adds a route descriptor to the router's list of route
-// descriptors, but only if it doesn't already exist.
-func (r *Router) addRouteDescriptor(method HTTPMethod, pattern *Pattern, metadata any) {
-	path := pattern.String()
-	if r.routeDescriptorMap == nil {
-		r.routeDescriptorMap = map[HTTPMethod]map[string]bool{}
+// mustHTTPMethod normalizes a method given to a binding method, panicking if
+// it is not a valid HTTP method.
+func mustHTTPMethod(method HTTPMethod) HTTPMethod {
+	normalizedMethod, err := HTTPMethodFromString(string(method))
+	if err != nil {
+		panic(err)
 	}
-	if r.routeDescriptors == nil {
-		r.routeDescriptors = []*RouteDescriptor{}
+	return normalizedMethod
+}
+
+// appendMethod appends method to methods if it is not already present.
+// Methods that represent all methods are ignored.
+func appendMethod(methods []HTTPMethod, method HTTPMethod) []HTTPMethod {
+	if method == All || slices.Contains(methods, method) {
+		return methods
 	}
-	if _, ok := r.routeDescriptorMap[method]; !ok {
-		r.routeDescriptorMap[method] = map[string]bool{}
+	return append(methods, method)
+}
+
+// joinMethods formats methods as the value of an Allow header.
+func joinMethods(methods []HTTPMethod) string {
+	methodStrs := make([]string, len(methods))
+	for i, method := range methods {
+		methodStrs[i] = string(method)
 	}
-	if _, ok := r.routeDescriptorMap[method][path]; ok {
-		return
+	return strings.Join(methodStrs, ", ")
+}
+
+// checkHandlersAndTransformers panics if any of the given values cannot be
+// used as a handler or transformer.
+func checkHandlersAndTransformers(handlersAndTransformers []any) {
+	for _, handlerOrTransformer := range handlersAndTransformers {
+		if _, ok := handlerOrTransformer.(Transformer); ok {
+			continue
+		} else if _, ok := handlerOrTransformer.(Handler); ok {
+			continue
+		} else if _, ok := handlerOrTransformer.(HandlerFunc); ok {
+			continue
+		} else if _, ok := handlerOrTransformer.(func(*Context)); ok {
+			continue
+		} else if _, ok := handlerOrTransformer.(http.Handler); ok {
+			continue
+		} else if _, ok := handlerOrTransformer.(func(http.ResponseWriter, *http.Request)); ok {
+			continue
+		}
+
+		panic("invalid handler type. Must be a Transformer, Handler, " +
+			"HandlerFunc, or net/http handler. Got: " + reflect.TypeOf(handlerOrTransformer).String())
 	}
-	r.routeDescriptorMap[method][path] = true
-	r.routeDescriptors = append(r.routeDescriptors, &RouteDescriptor{
-		Method:   method,
-		Pattern:  pattern,
-		Metadata: metadata,
-	})
 }
@@ -0,0 +1,311 @@
+package navaros
+
+import (
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// routerSnapshot is an immutable view of a router's routes. Requests are
+// routed using the snapshot current when they arrive, so routes added,
+// removed, or replaced while a request is in flight never affect it part way
+// through. A new snapshot is built the first time one is needed after the
+// router's routes change.
+type routerSnapshot struct {
+	handlerNodes      []*HandlerNode
+	routeTree         routeTree
+	namedHandlerNodes map[string]*HandlerNode
+	mountedRouters    []*Router
+	apiVersions       map[string]bool
+
+	routeDescriptorCache atomic.Pointer[routeDescriptorCache]
+}
+
+// newRouterSnapshot indexes a list of handler nodes. The list must not be
+// modified afterwards.
+func newRouterSnapshot(handlerNodes []*HandlerNode) *routerSnapshot {
+	snapshot := &routerSnapshot{handlerNodes: handlerNodes}
+	for _, handlerNode := range handlerNodes {
+		snapshot.routeTree.insert(handlerNode)
+		if handlerNode.Name != "" {
+			if snapshot.namedHandlerNodes == nil {
+				snapshot.namedHandlerNodes = map[string]*HandlerNode{}
+			}
+			snapshot.namedHandlerNodes[handlerNode.Name] = handlerNode
+		}
+		if handlerNode.Version != "" {
+			if snapshot.apiVersions == nil {
+				snapshot.apiVersions = map[string]bool{}
+			}
+			snapshot.apiVersions[handlerNode.Version] = true
+		}
+		for _, handlerOrTransformer := range handlerNode.HandlersAndTransformers {
+			if router, ok := handlerOrTransformer.(*Router); ok {
+				snapshot.mountedRouters = append(snapshot.mountedRouters, router)
+			}
+		}
+	}
+	return snapshot
+}
+
+// routerSnapshotRef records the snapshot a request is routed with for a
+// router.
+type routerSnapshotRef struct {
+	router   *Router
+	snapshot *routerSnapshot
+}
+
+// routerSnapshot returns the snapshot of router the request is routed with.
+// It is loaded the first time the request needs it, and kept for the rest of
+// the request, so routing, the Allow header of a 405, and the routers
+// mounted along the way all see the same routes even if they change while
+// the request is in flight.
+func (c *Context) routerSnapshot(router *Router) *routerSnapshot {
+	rootCtx := c.rootContext()
+	rootCtx.mu.Lock()
+	defer rootCtx.mu.Unlock()
+	for _, ref := range rootCtx.routerSnapshots {
+		if ref.router == router {
+			return ref.snapshot
+		}
+	}
+	snapshot := router.currentSnapshot()
+	rootCtx.routerSnapshots = append(rootCtx.routerSnapshots, routerSnapshotRef{router: router, snapshot: snapshot})
+	return snapshot
+}
+
+// currentSnapshot returns the router's current snapshot, building it if the
+// router's routes have changed since it was last built.
+func (r *Router) currentSnapshot() *routerSnapshot {
+	if snapshot := r.snapshot.Load(); snapshot != nil {
+		return snapshot
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if snapshot := r.snapshot.Load(); snapshot != nil {
+		return snapshot
+	}
+	snapshot := newRouterSnapshot(slices.Clone(r.handlerNodes))
+	r.snapshot.Store(snapshot)
+	return snapshot
+}
+
+// appendHandlerNode attaches a handler node to the end of the router's
+// handler chain. It panics if the node's name is already in use.
+func (r *Router) appendHandlerNode(handlerNode *HandlerNode) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checkNameAvailable(handlerNode.Name, nil)
+	handlerNode.seq = r.nextSeq
+	r.nextSeq += 1
+	r.handlerNodes = append(r.handlerNodes, handlerNode)
+	r.snapshot.Store(nil)
+}
+
+// removeHandlerNode removes the handler node with the given name from the
+// router's handler chain, or replaces it with replacement if it is not nil.
+// The replacement takes the removed node's place in the chain, and keeps its
+// name and visibility in the route descriptors if it does not have a name of
+// its own. False is returned if no handler node has the name.
+func (r *Router) removeHandlerNode(name string, replacement *HandlerNode) bool {
+	if name == "" {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index := slices.IndexFunc(r.handlerNodes, func(handlerNode *HandlerNode) bool {
+		return handlerNode.Name == name
+	})
+	if index == -1 {
+		return false
+	}
+
+	// Handler nodes are shared with existing snapshots, so the chain is
+	// copied rather than modified in place.
+	handlerNodes := slices.Clone(r.handlerNodes)
+	if replacement == nil {
+		handlerNodes = slices.Delete(handlerNodes, index, index+1)
+	} else {
+		if replacement.Name == "" {
+			replacement.Name = name
+		}
+		r.checkNameAvailable(replacement.Name, handlerNodes[index])
+		replacement.seq = handlerNodes[index].seq
+		replacement.isPublic = handlerNodes[index].isPublic
+		handlerNodes[index] = replacement
+	}
+	r.handlerNodes = handlerNodes
+	r.snapshot.Store(nil)
+	return true
+}
+
+// checkNameAvailable panics if a handler node other than except already has
+// the given name. It must be called with the router's lock held.
+func (r *Router) checkNameAvailable(name string, except *HandlerNode) {
+	if name == "" {
+		return
+	}
+	for _, handlerNode := range r.handlerNodes {
+		if handlerNode.Name == name && handlerNode != except {
+			panic("route name `" + name + "` is already in use")
+		}
+	}
+}
+
+// usesAPIVersions reports whether the snapshot's router, or any router
+// mounted on it, has routes bound to an API version. Mounted routers are
+// inspected with the snapshots the context is routed with.
+func (s *routerSnapshot) usesAPIVersions(ctx *Context) bool {
+	if len(s.apiVersions) != 0 {
+		return true
+	}
+	for _, router := range s.mountedRouters {
+		if ctx.routerSnapshot(router).usesAPIVersions(ctx) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAPIVersion reports whether the snapshot's router, or any router mounted
+// on it, has routes bound to the given API version. Mounted routers are
+// inspected with the snapshots the context is routed with.
+func (s *routerSnapshot) hasAPIVersion(ctx *Context, version string) bool {
+	if s.apiVersions[version] {
+		return true
+	}
+	for _, router := range s.mountedRouters {
+		if ctx.routerSnapshot(router).hasAPIVersion(ctx, version) {
+			return true
+		}
+	}
+	return false
+}
+
+// routeDescriptorKey identifies a route descriptor for a given method.
+type routeDescriptorKey struct {
+	method  HTTPMethod
+	host    string
+	path    string
+	version string
+}
+
+// routeDescriptorCache holds the route descriptors built for a snapshot,
+// along with the route descriptors of the router handlers mounted on it they
+// were built from.
+type routeDescriptorCache struct {
+	routeDescriptors        []*RouteDescriptor
+	mountedRouteDescriptors [][]*RouteDescriptor
+}
+
+// routeDescriptors returns the route descriptors of the snapshot's public
+// routes, and those of the routers mounted on it. They are built once and
+// reused until the descriptors of a mounted router change. The slice is
+// shared, so it's clipped to keep appends from writing into it.
+func (s *routerSnapshot) routeDescriptors() []*RouteDescriptor {
+	mountedRouteDescriptors := s.mountedRouteDescriptors()
+	if cache := s.routeDescriptorCache.Load(); cache != nil &&
+		slices.EqualFunc(cache.mountedRouteDescriptors, mountedRouteDescriptors, isSameRouteDescriptors) {
+		return cache.routeDescriptors
+	}
+
+	routeDescriptors := slices.Clip(s.appendRouteDescriptors(nil, mountedRouteDescriptors))
+	s.routeDescriptorCache.Store(&routeDescriptorCache{
+		routeDescriptors:        routeDescriptors,
+		mountedRouteDescriptors: mountedRouteDescriptors,
+	})
+	return routeDescriptors
+}
+
+// mountedRouteDescriptors returns the route descriptors of each router
+// handler mounted on the snapshot's router, in the order they are mounted.
+func (s *routerSnapshot) mountedRouteDescriptors() [][]*RouteDescriptor {
+	var mountedRouteDescriptors [][]*RouteDescriptor
+	for _, handlerNode := range s.handlerNodes {
+		for _, handlerOrTransformer := range handlerNode.HandlersAndTransformers {
+			if routerHandler, ok := handlerOrTransformer.(RouterHandler); ok {
+				mountedRouteDescriptors = append(mountedRouteDescriptors, routerHandler.RouteDescriptors())
+			}
+		}
+	}
+	return mountedRouteDescriptors
+}
+
+// isSameRouteDescriptors reports whether two slices of route descriptors are
+// the same slice. Routers return the same slice until their descriptors
+// change.
+func isSameRouteDescriptors(a, b []*RouteDescriptor) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// appendRouteDescriptors appends the route descriptors of the snapshot's
+// public routes, and those of the routers mounted on it, to
+// routeDescriptors. mountedRouteDescriptors holds the descriptors of each
+// mounted router handler, as returned by mountedRouteDescriptors.
+// Descriptors are only included once.
+func (s *routerSnapshot) appendRouteDescriptors(routeDescriptors []*RouteDescriptor, mountedRouteDescriptors [][]*RouteDescriptor) []*RouteDescriptor {
+	seen := map[routeDescriptorKey]bool{}
+	add := func(routeDescriptor *RouteDescriptor) {
+		key := routeDescriptorKey{
+			method:  routeDescriptor.Method,
+			path:    routeDescriptor.Pattern.String(),
+			version: routeDescriptor.Version,
+		}
+		if routeDescriptor.Host != nil {
+			key.host = routeDescriptor.Host.String()
+		}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		routeDescriptors = append(routeDescriptors, routeDescriptor)
+	}
+
+	mountIndex := 0
+	for _, handlerNode := range s.handlerNodes {
+		hasAddedOwnRouteDescriptor := false
+		for _, handlerOrTransformer := range handlerNode.HandlersAndTransformers {
+			if _, ok := handlerOrTransformer.(RouterHandler); ok {
+				mountPath := strings.TrimSuffix(handlerNode.Pattern.String(), "/**")
+				subRouteDescriptors := mountedRouteDescriptors[mountIndex]
+				mountIndex += 1
+				for _, routeDescriptor := range subRouteDescriptors {
+					subPattern, err := NewPattern(mountPath + routeDescriptor.Pattern.String())
+					if err != nil {
+						panic(err)
+					}
+					subHost := routeDescriptor.Host
+					if subHost == nil {
+						subHost = handlerNode.Host
+					}
+					subVersion := routeDescriptor.Version
+					if subVersion == "" {
+						subVersion = handlerNode.Version
+					}
+					add(&RouteDescriptor{
+						Method:   routeDescriptor.Method,
+						Host:     subHost,
+						Pattern:  subPattern,
+						Version:  subVersion,
+						Metadata: routeDescriptor.Metadata,
+					})
+				}
+			} else if handlerNode.isPublic && !hasAddedOwnRouteDescriptor {
+				add(&RouteDescriptor{
+					Method:   handlerNode.Method,
+					Host:     handlerNode.Host,
+					Pattern:  handlerNode.Pattern,
+					Version:  handlerNode.Version,
+					Metadata: handlerNode.metadata,
+				})
+				hasAddedOwnRouteDescriptor = true
+			}
+		}
+	}
+
+	return routeDescriptors
+}
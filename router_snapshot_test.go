@@ -0,0 +1,223 @@
+package navaros_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/RobertWHurst/navaros"
+)
+
+func TestRouterRemove(t *testing.T) {
+	router := navaros.NewRouter()
+	router.PublicGet("/a", func(ctx *navaros.Context) { ctx.Body = "a" }, navaros.WithName("a"))
+	router.PublicGet("/b", func(ctx *navaros.Context) { ctx.Body = "b" }, navaros.WithName("b"))
+
+	if !router.Remove("a") {
+		t.Fatal("expected the route to be removed")
+	}
+	if router.Remove("a") {
+		t.Error("expected removing the route again to fail")
+	}
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/a", nil))
+	if res.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", res.Code)
+	}
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/b", nil))
+	if res.Body.String() != "b" {
+		t.Errorf("expected b, got %s", res.Body.String())
+	}
+
+	descriptors := router.RouteDescriptors()
+	if len(descriptors) != 1 || descriptors[0].Pattern.String() != "/b" {
+		t.Errorf("expected only the /b descriptor, got %v", descriptors)
+	}
+	if _, err := router.URLFor("a", nil, nil); err == nil {
+		t.Error("expected the removed route's name to be unknown")
+	}
+
+	// The name can be reused once removed.
+	router.Get("/c", func(ctx *navaros.Context) {}, navaros.WithName("a"))
+}
+
+func TestRouterReplace(t *testing.T) {
+	router := navaros.NewRouter()
+	router.PublicGet("/users/:id", func(ctx *navaros.Context) { ctx.Body = "old" }, navaros.WithName("user"))
+	router.Get("/users/:id", func(ctx *navaros.Context) { ctx.Body = "fallback" })
+
+	ok := router.Replace("user", navaros.Get, "/people/:id", func(ctx *navaros.Context) {
+		if ctx.Params().Get("id") == "skip" {
+			ctx.Next()
+			return
+		}
+		ctx.Body = "new"
+	})
+	if !ok {
+		t.Fatal("expected the route to be replaced")
+	}
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/people/1", nil))
+	if res.Body.String() != "new" {
+		t.Errorf("expected new, got %s", res.Body.String())
+	}
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/users/1", nil))
+	if res.Body.String() != "fallback" {
+		t.Errorf("expected fallback, got %s", res.Body.String())
+	}
+
+	url, err := router.URLFor("user", navaros.RequestParams{"id": "1"}, nil)
+	if err != nil || url != "/people/1" {
+		t.Errorf("expected /people/1, got %s (%v)", url, err)
+	}
+	descriptors := router.RouteDescriptors()
+	if len(descriptors) != 1 || descriptors[0].Pattern.String() != "/people/:id" {
+		t.Errorf("expected only the /people/:id descriptor, got %v", descriptors)
+	}
+
+	if router.Replace("missing", navaros.Get, "/", func(ctx *navaros.Context) {}) {
+		t.Error("expected replacing an unknown route to fail")
+	}
+}
+
+func TestRouterReplaceKeepsPosition(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/items/:id", func(ctx *navaros.Context) { ctx.Body = "first" }, navaros.WithName("first"))
+	router.Get("/items/:id", func(ctx *navaros.Context) { ctx.Body = "second" })
+
+	router.Replace("first", navaros.Get, "/items/:id", func(ctx *navaros.Context) { ctx.Body = "replaced" })
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/items/1", nil))
+	if res.Body.String() != "replaced" {
+		t.Errorf("expected replaced, got %s", res.Body.String())
+	}
+}
+
+func TestRouterRemoveUpdatesParentDescriptors(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.PublicGet("/a", func(ctx *navaros.Context) {}, navaros.WithName("a"))
+	subRouter.PublicGet("/b", func(ctx *navaros.Context) {})
+
+	router := navaros.NewRouter()
+	router.Use("/sub", subRouter)
+
+	if len(router.RouteDescriptors()) != 2 {
+		t.Fatalf("expected 2 descriptors, got %v", router.RouteDescriptors())
+	}
+	subRouter.Remove("a")
+	descriptors := router.RouteDescriptors()
+	if len(descriptors) != 1 || descriptors[0].Pattern.String() != "/sub/b" {
+		t.Errorf("expected only the /sub/b descriptor, got %v", descriptors)
+	}
+}
+
+func TestRouterRouteDescriptorsAreCached(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.PublicGet("/a", func(ctx *navaros.Context) {})
+
+	router := navaros.NewRouter()
+	router.PublicGet("/b", func(ctx *navaros.Context) {})
+	router.Use("/sub", subRouter)
+
+	first := router.RouteDescriptors()
+	second := router.RouteDescriptors()
+	if len(first) != 2 || &first[0] != &second[0] {
+		t.Errorf("expected the descriptors to be reused, got %v and %v", first, second)
+	}
+
+	subRouter.PublicGet("/c", func(ctx *navaros.Context) {})
+	third := router.RouteDescriptors()
+	if len(third) != 3 || third[2].Pattern.String() != "/sub/c" {
+		t.Errorf("expected the descriptors to include /sub/c, got %v", third)
+	}
+}
+
+func TestRouterRequestKeepsItsSnapshot(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.Get("/item", func(ctx *navaros.Context) { ctx.Next() })
+	subRouter.Put("/item", func(ctx *navaros.Context) {}, navaros.WithName("put"))
+
+	router := navaros.NewRouter()
+	router.Use(func(ctx *navaros.Context) {
+		// Changes made while the request is in flight must not affect it.
+		subRouter.Remove("put")
+		router.Delete("/sub/item", func(ctx *navaros.Context) {})
+		ctx.Next()
+	})
+	router.Use("/sub", subRouter)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("POST", "/sub/item", nil))
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", res.Code)
+	}
+	if allow := res.Header().Get("Allow"); allow != "GET, PUT, HEAD, OPTIONS" {
+		t.Errorf("expected the Allow header from the routes the request started with, got %s", allow)
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("POST", "/sub/item", nil))
+	if allow := res.Header().Get("Allow"); allow != "GET, DELETE, HEAD, OPTIONS" {
+		t.Errorf("expected the Allow header from the changed routes, got %s", allow)
+	}
+}
+
+func TestRouterGroupReplace(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Group("/api", func(g *navaros.Router) {
+		g.Use(func(ctx *navaros.Context) {
+			ctx.Headers.Set("X-Group", "api")
+			ctx.Next()
+		})
+		g.Get("/status", func(ctx *navaros.Context) { ctx.Body = "old" }, navaros.WithName("status"))
+		g.Replace("status", navaros.Get, "/health", func(ctx *navaros.Context) { ctx.Body = "new" })
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/api/health", nil))
+	if res.Body.String() != "new" || res.Header().Get("X-Group") != "api" {
+		t.Errorf("expected new with the group's middleware, got %s", res.Body.String())
+	}
+}
+
+func TestRouterConcurrentRouteChanges(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/stable", func(ctx *navaros.Context) { ctx.Body = "stable" })
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i += 1 {
+			name := "route" + strconv.Itoa(i)
+			router.PublicGet("/routes/"+strconv.Itoa(i), func(ctx *navaros.Context) {}, navaros.WithName(name))
+			router.Replace(name, navaros.Get, "/routes/"+strconv.Itoa(i), func(ctx *navaros.Context) {})
+			if i%2 == 0 {
+				router.Remove(name)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i += 1 {
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, httptest.NewRequest("GET", "/stable", nil))
+			if res.Body.String() != "stable" {
+				t.Errorf("expected stable, got %s", res.Body.String())
+			}
+			router.RouteDescriptors()
+		}
+	}()
+	wg.Wait()
+
+	if len(router.RouteDescriptors()) != 50 {
+		t.Errorf("expected 50 descriptors, got %d", len(router.RouteDescriptors()))
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	neturl "net/url"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -718,6 +721,562 @@ func TestRouterPublicHead(t *testing.T) {
 	}
 }
 
+func TestRouterMethod(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Method("PROPFIND", "/files/:name", func(ctx *navaros.Context) {
+		ctx.Status = 207
+		ctx.Body = string(ctx.Method()) + " " + ctx.Params().Get("name")
+	})
+	router.Method(navaros.Query, "/search", func(ctx *navaros.Context) {
+		ctx.Status = 200
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("PROPFIND", "/files/a.txt", nil))
+
+	if res.Code != 207 {
+		t.Errorf("expected 207, got %d", res.Code)
+	}
+	if res.Body.String() != "PROPFIND a.txt" {
+		t.Errorf("expected 'PROPFIND a.txt', got %q", res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("QUERY", "/search", nil))
+
+	if res.Code != 200 {
+		t.Errorf("expected 200, got %d", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/files/a.txt", nil))
+
+	if res.Header().Get("Allow") != "PROPFIND, OPTIONS" {
+		t.Errorf("expected Allow header to be PROPFIND, OPTIONS, got %q", res.Header().Get("Allow"))
+	}
+}
+
+func TestRouterMethodPanicsOnInvalidMethod(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for an invalid method")
+		}
+	}()
+
+	router := navaros.NewRouter()
+	router.Method("NOT VALID", "/test", func(ctx *navaros.Context) {})
+}
+
+func TestRouterPublicMethod(t *testing.T) {
+	router := navaros.NewRouter()
+	router.PublicMethod("propfind", "/files/:name", func(ctx *navaros.Context) {})
+
+	descriptors := router.RouteDescriptors()
+	if len(descriptors) != 1 {
+		t.Fatalf("expected 1 descriptor, got %d", len(descriptors))
+	}
+	if descriptors[0].Method != "PROPFIND" {
+		t.Errorf("expected PROPFIND method, got %s", descriptors[0].Method)
+	}
+}
+
+func TestRouterWithHost(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/", func(ctx *navaros.Context) {
+		ctx.Body = "tenant " + ctx.Params().Get("tenant")
+	}, navaros.WithHost(":tenant.example.com"))
+	router.Get("/", func(ctx *navaros.Context) {
+		ctx.Body = "root"
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Host = "acme.example.com:8080"
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Body.String() != "tenant acme" {
+		t.Errorf("expected tenant acme, got %s", res.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Host = "example.org"
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Body.String() != "root" {
+		t.Errorf("expected root, got %s", res.Body.String())
+	}
+}
+
+func TestRouterUseWithHostMountsSubRouter(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.PublicGet("/users/:id", func(ctx *navaros.Context) {
+		ctx.Body = ctx.Params().Get("tenant") + " " + ctx.Params().Get("id")
+	})
+
+	router := navaros.NewRouter()
+	router.Use("/api", subRouter, navaros.WithHost(":tenant.example.com"))
+
+	req := httptest.NewRequest("GET", "/api/users/7", nil)
+	req.Host = "acme.example.com"
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Body.String() != "acme 7" {
+		t.Errorf("expected acme 7, got %s", res.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/api/users/7", nil)
+	req.Host = "example.com"
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", res.Code)
+	}
+
+	descriptors := router.RouteDescriptors()
+	if len(descriptors) != 1 {
+		t.Fatalf("expected 1 descriptor, got %d", len(descriptors))
+	}
+	if descriptors[0].Host == nil || descriptors[0].Host.String() != ":tenant.example.com" {
+		t.Errorf("expected host :tenant.example.com, got %v", descriptors[0].Host)
+	}
+	if descriptors[0].Pattern.String() != "/api/users/:id" {
+		t.Errorf("expected /api/users/:id pattern, got %s", descriptors[0].Pattern)
+	}
+}
+
+func TestRouterMethodNotAllowedWithHost(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/users", func(ctx *navaros.Context) {}, navaros.WithHost("api.example.com"))
+	router.Post("/users", func(ctx *navaros.Context) {}, navaros.WithHost("admin.example.com"))
+
+	req := httptest.NewRequest("DELETE", "/users", nil)
+	req.Host = "api.example.com"
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", res.Code)
+	}
+	if res.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Errorf("expected Allow of GET, HEAD, OPTIONS, got %s", res.Header().Get("Allow"))
+	}
+}
+
+func TestRouterTypedParamFallsThrough(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/users/:id<int>", func(ctx *navaros.Context) {
+		id, err := ctx.Params().Int("id")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		ctx.Body = strconv.Itoa(id * 2)
+	})
+	router.Get("/users/:name", func(ctx *navaros.Context) {
+		ctx.Body = "name " + ctx.Params().Get("name")
+	})
+
+	req := httptest.NewRequest("GET", "/users/21", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Body.String() != "42" {
+		t.Errorf("expected 42, got %s", res.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/users/alice", nil)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Body.String() != "name alice" {
+		t.Errorf("expected name alice, got %s", res.Body.String())
+	}
+}
+
+func TestRouterURLFor(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/users/:id", func(ctx *navaros.Context) {}, navaros.WithName("user"))
+
+	url, err := router.URLFor("user", navaros.RequestParams{"id": "a b/c"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if url != "/users/a%20b%2Fc" {
+		t.Errorf("expected /users/a%%20b%%2Fc, got %s", url)
+	}
+
+	url, err = router.URLFor("user", navaros.RequestParams{"id": "1"}, neturl.Values{"tab": {"posts & likes"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if url != "/users/1?tab=posts+%26+likes" {
+		t.Errorf("expected /users/1?tab=posts+%%26+likes, got %s", url)
+	}
+
+	router.Get("/files/:path+", func(ctx *navaros.Context) {}, navaros.WithName("file"))
+	url, err = router.URLFor("file", navaros.RequestParams{"path": "docs/read me.md"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if url != "/files/docs/read%20me.md" {
+		t.Errorf("expected /files/docs/read%%20me.md, got %s", url)
+	}
+
+	if _, err := router.URLFor("user", nil, nil); err == nil {
+		t.Error("expected an error for a missing parameter")
+	}
+	if _, err := router.URLFor("missing", nil, nil); err == nil {
+		t.Error("expected an error for an unknown route name")
+	}
+}
+
+func TestRouterURLForNestedRouters(t *testing.T) {
+	postsRouter := navaros.NewRouter()
+	postsRouter.Get("/", func(ctx *navaros.Context) { ctx.Body = "posts" }, navaros.WithName("posts"))
+	postsRouter.Get("/:postId", func(ctx *navaros.Context) {}, navaros.WithName("post"))
+
+	orgRouter := navaros.NewRouter()
+	orgRouter.Use("/posts", postsRouter)
+
+	router := navaros.NewRouter()
+	router.Use("/orgs/:org", orgRouter)
+
+	url, err := router.URLFor("post", navaros.RequestParams{"org": "acme", "postId": "7"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if url != "/orgs/acme/posts/7" {
+		t.Errorf("expected /orgs/acme/posts/7, got %s", url)
+	}
+
+	url, err = router.URLFor("posts", navaros.RequestParams{"org": "acme"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if url != "/orgs/acme/posts" {
+		t.Errorf("expected /orgs/acme/posts, got %s", url)
+	}
+
+	req := httptest.NewRequest("GET", url, nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Errorf("expected generated url to be routable, got %d", res.Code)
+	}
+}
+
+func TestRouterWithNamePanicsOnDuplicateName(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/a", func(ctx *navaros.Context) {}, navaros.WithName("a"))
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a duplicate route name")
+		}
+	}()
+	router.Get("/b", func(ctx *navaros.Context) {}, navaros.WithName("a"))
+}
+
+func TestRouterGroup(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Group("/admin", func(g *navaros.Router) {
+		g.Use(func(ctx *navaros.Context) {
+			ctx.Headers.Set("X-Group", "admin")
+			ctx.Next()
+		})
+		g.Get("/", func(ctx *navaros.Context) {
+			ctx.Body = "admin home"
+		})
+		g.Get("/users/:id", func(ctx *navaros.Context) {
+			ctx.Body = "admin user " + ctx.Params().Get("id")
+		})
+	})
+	router.Get("/users/:id", func(ctx *navaros.Context) {
+		ctx.Body = "user " + ctx.Params().Get("id")
+	})
+
+	cases := []struct {
+		path          string
+		expectedBody  string
+		expectedGroup string
+	}{
+		{"/admin", "admin home", "admin"},
+		{"/admin/users/1", "admin user 1", "admin"},
+		{"/users/1", "user 1", ""},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", c.path, nil)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		if res.Body.String() != c.expectedBody {
+			t.Errorf("expected %s for %s, got %s", c.expectedBody, c.path, res.Body.String())
+		}
+		if res.Header().Get("X-Group") != c.expectedGroup {
+			t.Errorf("expected X-Group %q for %s, got %q", c.expectedGroup, c.path, res.Header().Get("X-Group"))
+		}
+	}
+}
+
+func TestRouterGroupMiddlewareOnlyRunsForMatchingRoutes(t *testing.T) {
+	middlewareCalls := 0
+	router := navaros.NewRouter()
+	router.Group("/api", func(g *navaros.Router) {
+		g.Use(func(ctx *navaros.Context) {
+			middlewareCalls += 1
+			ctx.Next()
+		})
+		g.Get("/status", func(ctx *navaros.Context) {
+			ctx.Body = "ok"
+		})
+	})
+
+	req := httptest.NewRequest("GET", "/api/missing", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", res.Code)
+	}
+	if middlewareCalls != 0 {
+		t.Errorf("expected group middleware not to run, ran %d times", middlewareCalls)
+	}
+
+	req = httptest.NewRequest("GET", "/api/status", nil)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if middlewareCalls != 1 {
+		t.Errorf("expected group middleware to run once, ran %d times", middlewareCalls)
+	}
+}
+
+func TestRouterGroupWrapAndOptions(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Group("/v1", func(g *navaros.Router) {
+		g.Wrap(func(ctx *navaros.Context) {
+			ctx.Next()
+			ctx.Headers.Set("X-Wrapped", "true")
+		})
+		g.PublicGet("/items", func(ctx *navaros.Context) {
+			ctx.Body = "items"
+		})
+		g.PublicGet("/things", func(ctx *navaros.Context) {
+			ctx.Body = "things"
+		}, navaros.WithMetadata("override"))
+		g.Group("/nested", func(g *navaros.Router) {
+			g.PublicGet("/", func(ctx *navaros.Context) {
+				ctx.Body = "nested"
+			})
+		})
+	}, navaros.WithMetadata("default"))
+	router.Get("/outside", func(ctx *navaros.Context) {
+		ctx.Body = "outside"
+	})
+
+	req := httptest.NewRequest("GET", "/v1/nested", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Body.String() != "nested" || res.Header().Get("X-Wrapped") != "true" {
+		t.Errorf("expected wrapped nested response, got %s %q", res.Body.String(), res.Header().Get("X-Wrapped"))
+	}
+
+	req = httptest.NewRequest("GET", "/outside", nil)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Header().Get("X-Wrapped") != "" {
+		t.Error("expected routes outside the group not to be wrapped")
+	}
+
+	descriptors := router.RouteDescriptors()
+	if len(descriptors) != 3 {
+		t.Fatalf("expected 3 descriptors, got %d", len(descriptors))
+	}
+	expected := []struct {
+		pattern  string
+		metadata any
+	}{
+		{"/v1/items", "default"},
+		{"/v1/things", "override"},
+		{"/v1/nested", "default"},
+	}
+	for i, e := range expected {
+		if descriptors[i].Pattern.String() != e.pattern {
+			t.Errorf("expected pattern %s, got %s", e.pattern, descriptors[i].Pattern)
+		}
+		if descriptors[i].Metadata != e.metadata {
+			t.Errorf("expected metadata %v for %s, got %v", e.metadata, e.pattern, descriptors[i].Metadata)
+		}
+	}
+}
+
+func TestRouterGroupMountsSubRouter(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.Get("/hello", func(ctx *navaros.Context) {
+		user, _ := ctx.Get("user")
+		ctx.Body = "hello " + user.(string)
+	})
+
+	router := navaros.NewRouter()
+	router.Group("/api", func(g *navaros.Router) {
+		g.Use(func(ctx *navaros.Context) {
+			ctx.Set("user", "alice")
+			ctx.Next()
+		})
+		g.Use(subRouter)
+	})
+
+	req := httptest.NewRequest("GET", "/api/hello", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Body.String() != "hello alice" {
+		t.Errorf("expected hello alice, got %s", res.Body.String())
+	}
+}
+
+func TestRouterGroupPanicsOnName(t *testing.T) {
+	router := navaros.NewRouter()
+
+	defer func() {
+		message, _ := recover().(string)
+		if !strings.Contains(message, "WithName cannot be given to Group") {
+			t.Errorf("expected a panic for a group level route name, got %q", message)
+		}
+	}()
+	router.Group("/admin", func(g *navaros.Router) {
+		g.Get("/users", func(ctx *navaros.Context) {})
+		g.Get("/posts", func(ctx *navaros.Context) {})
+	}, navaros.WithName("admin"))
+}
+
+func TestRouterEncodedPathParams(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/objects/:key", func(ctx *navaros.Context) {
+		ctx.Body = "key " + ctx.Params().Get("key")
+	})
+	router.Get("/objects/:dir/:name", func(ctx *navaros.Context) {
+		ctx.Body = "dir " + ctx.Params().Get("dir")
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/objects/photos%2F2024%2Fcat%20pic.jpg", nil))
+	if res.Body.String() != "key photos/2024/cat pic.jpg" {
+		t.Errorf("expected key photos/2024/cat pic.jpg, got %s", res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/objects/photos/cat.jpg", nil))
+	if res.Body.String() != "dir photos" {
+		t.Errorf("expected dir photos, got %s", res.Body.String())
+	}
+}
+
+func TestRouterMixedSegments(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/files/:name.:ext", func(ctx *navaros.Context) {
+		ctx.Body = ctx.Params().Get("name") + " " + ctx.Params().Get("ext")
+	}, navaros.WithName("file"))
+	router.Get("/files/:name", func(ctx *navaros.Context) {
+		ctx.Body = "no extension"
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/files/photo.jpg", nil))
+	if res.Body.String() != "photo jpg" {
+		t.Errorf("expected photo jpg, got %s", res.Body.String())
+	}
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/files/photo", nil))
+	if res.Body.String() != "no extension" {
+		t.Errorf("expected no extension, got %s", res.Body.String())
+	}
+
+	url, err := router.URLFor("file", navaros.RequestParams{"name": "photo", "ext": "jpg"}, nil)
+	if err != nil || url != "/files/photo.jpg" {
+		t.Errorf("expected /files/photo.jpg, got %s (%v)", url, err)
+	}
+}
+
+func TestRouterSubPatternsSpanningSegments(t *testing.T) {
+	router := navaros.NewRouter()
+	router.SetCaseInsensitive(true)
+	router.Get("/files/:path(.*)", func(ctx *navaros.Context) {
+		ctx.Body = "files " + ctx.Params().Get("path")
+	})
+	router.Get("/raw/:path(.+)", func(ctx *navaros.Context) {
+		ctx.Body = "raw " + ctx.Params().Get("path")
+	})
+	router.Get("/a/(.*)", func(ctx *navaros.Context) {
+		ctx.Body = "a"
+	})
+
+	cases := []struct {
+		path     string
+		expected string
+	}{
+		{"/files/a/b/c", "files a/b/c"},
+		{"/Files/a/B", "files a/B"},
+		{"/raw/a/b", "raw a/b"},
+		{"/raw/a%20b/c", "raw a b/c"},
+		{"/a/x/y", "a"},
+	}
+	for _, c := range cases {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest("GET", c.path, nil))
+		if res.Body.String() != c.expected {
+			t.Errorf("%s: expected %q, got %q", c.path, c.expected, res.Body.String())
+		}
+	}
+}
+
+func TestRouterWildcards(t *testing.T) {
+	staticRouter := navaros.NewRouter()
+	staticRouter.Get("/**", func(ctx *navaros.Context) {
+		ctx.Body = strings.Join(ctx.Wildcards(), ",")
+	})
+
+	router := navaros.NewRouter()
+	router.Get("/proxy/*/**", func(ctx *navaros.Context) {
+		ctx.Body = strings.Join(ctx.Wildcards(), ",")
+	})
+	router.Get("/files/**path", func(ctx *navaros.Context) {
+		ctx.Body = ctx.Params().Get("path")
+	})
+	router.Use("/static", staticRouter)
+
+	cases := []struct {
+		path     string
+		expected string
+	}{
+		{"/proxy/api/users/1", "api,users/1"},
+		{"/proxy/api", "api,"},
+		{"/files/a/b.txt", "a/b.txt"},
+		{"/static/css/site.css", "css/site.css"},
+	}
+	for _, c := range cases {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest("GET", c.path, nil))
+		if res.Body.String() != c.expected {
+			t.Errorf("%s: expected %q, got %q", c.path, c.expected, res.Body.String())
+		}
+	}
+}
+
+func TestRouterRawWildcards(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/proxy/**", func(ctx *navaros.Context) {
+		ctx.Body = strings.Join(ctx.Wildcards(), ",") + " " + strings.Join(ctx.RawWildcards(), ",")
+	})
+
+	cases := []struct {
+		path     string
+		expected string
+	}{
+		{"/proxy/a%2Fb/c", "a/b/c a%2Fb/c"},
+		{"/proxy/a/b/c", "a/b/c a/b/c"},
+		{"/proxy/x%20y", "x y x%20y"},
+	}
+	for _, c := range cases {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest("GET", c.path, nil))
+		if res.Body.String() != c.expected {
+			t.Errorf("%s: expected %q, got %q", c.path, c.expected, res.Body.String())
+		}
+	}
+}
+
 func TestRouterLookup(t *testing.T) {
 	router := navaros.NewRouter()
 	handler := func(ctx *navaros.Context) {}
@@ -770,8 +1329,431 @@ func TestRouterMethodNotAllowed(t *testing.T) {
 	res := httptest.NewRecorder()
 	router.ServeHTTP(res, req)
 
+	if res.Code != 405 {
+		t.Errorf("expected 405 for method not allowed, got %d", res.Code)
+	}
+}
+
+func TestRouterMethodNotAllowedAllowHeader(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/test", func(ctx *navaros.Context) {
+		ctx.Status = 200
+	})
+	router.Put("/test", func(ctx *navaros.Context) {
+		ctx.Status = 200
+	})
+
+	req := httptest.NewRequest("POST", "/test", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	if res.Code != 405 {
+		t.Errorf("expected 405 for method not allowed, got %d", res.Code)
+	}
+	if res.Header().Get("Allow") != "GET, PUT, HEAD, OPTIONS" {
+		t.Errorf("expected Allow header to be GET, PUT, HEAD, OPTIONS, got %q", res.Header().Get("Allow"))
+	}
+}
+
+func TestRouterMethodNotAllowedWithSubRouter(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.Get("/users/:id", func(ctx *navaros.Context) {
+		ctx.Status = 200
+	})
+	subRouter.Delete("/users/:id", func(ctx *navaros.Context) {
+		ctx.Status = 204
+	})
+
+	router := navaros.NewRouter()
+	router.Use(func(ctx *navaros.Context) {
+		ctx.Next()
+	})
+	router.Use("/api", subRouter)
+	router.Patch("/api/users/:id", func(ctx *navaros.Context) {
+		ctx.Status = 200
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("POST", "/api/users/1", nil))
+
+	if res.Code != 405 {
+		t.Errorf("expected 405, got %d", res.Code)
+	}
+	if res.Header().Get("Allow") != "GET, DELETE, PATCH, HEAD, OPTIONS" {
+		t.Errorf("expected Allow header to be GET, DELETE, PATCH, HEAD, OPTIONS, got %q", res.Header().Get("Allow"))
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("POST", "/api/posts/1", nil))
+
+	if res.Code != 404 {
+		t.Errorf("expected 404 for a path without routes, got %d", res.Code)
+	}
+}
+
+func TestRouterMethodNotAllowedHandler(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/test", func(ctx *navaros.Context) {
+		ctx.Status = 200
+	})
+	router.MethodNotAllowed(func(ctx *navaros.Context) {
+		ctx.Body = "use " + ctx.Headers.Get("Allow")
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("POST", "/test", nil))
+
+	if res.Code != 405 {
+		t.Errorf("expected 405, got %d", res.Code)
+	}
+	if res.Body.String() != "use GET, HEAD, OPTIONS" {
+		t.Errorf("expected body to be 'use GET, HEAD, OPTIONS', got %q", res.Body.String())
+	}
+}
+
+func TestRouterNotFoundHandlers(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/test", func(ctx *navaros.Context) {
+		ctx.Status = 200
+	})
+	router.NotFound(func(ctx *navaros.Context) {
+		ctx.Body = "nothing at " + ctx.Path()
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/missing", nil))
+	if res.Code != 404 || res.Body.String() != "nothing at /missing" {
+		t.Errorf("expected 404 nothing at /missing, got %d %q", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("POST", "/test", nil))
+	if res.Code != 405 {
+		t.Errorf("expected method not allowed requests to keep their 405, got %d", res.Code)
+	}
+}
+
+func TestRouterNotFoundInSubRouter(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.Get("/users/:id", func(ctx *navaros.Context) {
+		ctx.Status = 200
+	})
+	subRouter.NotFound(func(ctx *navaros.Context) {
+		ctx.Body = "no such api route"
+	})
+
+	router := navaros.NewRouter()
+	router.Use("/api", subRouter)
+	router.Get("/api/fallback", func(ctx *navaros.Context) {
+		ctx.Body = "fallback"
+	})
+	router.NotFound(func(ctx *navaros.Context) {
+		ctx.Body = "no such page"
+	})
+
+	cases := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{"GET", "/api/fallback", 404, "no such api route"},
+		{"GET", "/about", 404, "no such page"},
+		{"DELETE", "/api/users/1", 405, ""},
+		{"GET", "/api/users/1", 200, ""},
+	}
+	for _, c := range cases {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest(c.method, c.path, nil))
+		if res.Code != c.status || res.Body.String() != c.body {
+			t.Errorf("%s %s: expected %d %q, got %d %q", c.method, c.path, c.status, c.body, res.Code, res.Body.String())
+		}
+	}
+}
+
+func TestRouterOnError(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Use(func(ctx *navaros.Context) {
+		ctx.SetResponseBodyMarshaller(func(ctx *navaros.Context, from any) (io.Reader, error) {
+			return strings.NewReader("encoded " + from.(map[string]string)["error"]), nil
+		})
+		ctx.Next()
+	})
+	router.OnError(func(ctx *navaros.Context, err error) {
+		if ctx.Status != 500 {
+			t.Errorf("expected the status to default to 500, got %d", ctx.Status)
+		}
+		ctx.Status = http.StatusBadRequest
+		ctx.Body = map[string]string{"error": err.Error()}
+	})
+	router.Get("/error", func(ctx *navaros.Context) {
+		ctx.Body = "partial"
+		ctx.Error = errors.New("bad input")
+	})
+	router.Get("/panic", func(ctx *navaros.Context) {
+		panic("boom")
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/error", nil))
+	if res.Code != http.StatusBadRequest || res.Body.String() != "encoded bad input" {
+		t.Errorf("expected 400 encoded bad input, got %d %q", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/panic", nil))
+	if res.Code != http.StatusBadRequest || res.Body.String() != "encoded boom" {
+		t.Errorf("expected 400 encoded boom, got %d %q", res.Code, res.Body.String())
+	}
+}
+
+func TestRouterOnErrorInSubRouter(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.OnError(func(ctx *navaros.Context, err error) {
+		ctx.Body = "api error: " + err.Error()
+	})
+	subRouter.Get("/fail", func(ctx *navaros.Context) {
+		ctx.Error = errors.New("api failed")
+	})
+
+	router := navaros.NewRouter()
+	router.OnError(func(ctx *navaros.Context, err error) {
+		ctx.Body = "page error: " + err.Error()
+	})
+	router.Use("/api", subRouter)
+	router.Get("/fail", func(ctx *navaros.Context) {
+		ctx.Error = errors.New("page failed")
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/api/fail", nil))
+	if res.Code != 500 || res.Body.String() != "api error: api failed" {
+		t.Errorf("expected the sub router's error response, got %d %q", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/fail", nil))
+	if res.Code != 500 || res.Body.String() != "page error: page failed" {
+		t.Errorf("expected the router's error response, got %d %q", res.Code, res.Body.String())
+	}
+}
+
+func TestRouterSetAutoMethodNotAllowed(t *testing.T) {
+	router := navaros.NewRouter()
+	router.SetAutoMethodNotAllowed(false)
+	router.Get("/test", func(ctx *navaros.Context) {
+		ctx.Status = 200
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("POST", "/test", nil))
+
 	if res.Code != 404 {
-		t.Errorf("expected 404 for method not allowed, got %d", res.Code)
+		t.Errorf("expected 404 with automatic 405 disabled, got %d", res.Code)
+	}
+	if res.Header().Get("Allow") != "" {
+		t.Errorf("expected no Allow header, got %q", res.Header().Get("Allow"))
+	}
+}
+
+func TestRouterAutoOptions(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.Put("/users/:id", func(ctx *navaros.Context) {
+		ctx.Status = 200
+	})
+
+	router := navaros.NewRouter()
+	router.Get("/api/users/:id", func(ctx *navaros.Context) {
+		ctx.Status = 200
+	})
+	router.Use("/api", subRouter)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("OPTIONS", "/api/users/1", nil))
+
+	if res.Code != 204 {
+		t.Errorf("expected 204, got %d", res.Code)
+	}
+	if res.Header().Get("Allow") != "GET, PUT, HEAD, OPTIONS" {
+		t.Errorf("expected Allow header to be GET, PUT, HEAD, OPTIONS, got %q", res.Header().Get("Allow"))
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("OPTIONS", "/api/posts", nil))
+
+	if res.Code != 404 {
+		t.Errorf("expected 404 for a path without routes, got %d", res.Code)
+	}
+}
+
+func TestRouterAutoOptionsExplicitHandlerTakesPrecedence(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/test", func(ctx *navaros.Context) {
+		ctx.Status = 200
+	})
+	router.Options("/test", func(ctx *navaros.Context) {
+		ctx.Status = 200
+		ctx.Headers.Set("Allow", "GET")
+		ctx.Body = "custom"
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("OPTIONS", "/test", nil))
+
+	if res.Code != 200 {
+		t.Errorf("expected 200, got %d", res.Code)
+	}
+	if res.Body.String() != "custom" {
+		t.Errorf("expected body from explicit handler, got %q", res.Body.String())
+	}
+	if res.Header().Get("Allow") != "GET" {
+		t.Errorf("expected Allow header from explicit handler, got %q", res.Header().Get("Allow"))
+	}
+}
+
+func TestRouterSetAutoOptions(t *testing.T) {
+	router := navaros.NewRouter()
+	router.SetAutoOptions(false)
+	router.Get("/test", func(ctx *navaros.Context) {
+		ctx.Status = 200
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("OPTIONS", "/test", nil))
+
+	if res.Code != 405 {
+		t.Errorf("expected 405 with automatic OPTIONS disabled, got %d", res.Code)
+	}
+	if res.Header().Get("Allow") != "GET, HEAD" {
+		t.Errorf("expected Allow header to be GET, HEAD, got %q", res.Header().Get("Allow"))
+	}
+}
+
+func TestRouterAutoHead(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/test", func(ctx *navaros.Context) {
+		ctx.Status = 201
+		ctx.Headers.Set("X-Test", "value")
+		ctx.Body = "Hello World"
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("HEAD", "/test", nil))
+
+	if res.Code != 201 {
+		t.Errorf("expected 201, got %d", res.Code)
+	}
+	if res.Header().Get("X-Test") != "value" {
+		t.Errorf("expected X-Test header to be kept, got %q", res.Header().Get("X-Test"))
+	}
+	if res.Header().Get("Content-Length") != "11" {
+		t.Errorf("expected Content-Length 11, got %q", res.Header().Get("Content-Length"))
+	}
+	if res.Body.Len() != 0 {
+		t.Errorf("expected no body, got %q", res.Body.String())
+	}
+}
+
+func TestRouterAutoHeadWithWrittenBody(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Get("/test", func(ctx *navaros.Context) {
+		_, _ = ctx.Write([]byte("Hello"))
+		_, _ = ctx.Write([]byte(" World"))
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("HEAD", "/test", nil))
+
+	if res.Code != 200 {
+		t.Errorf("expected 200, got %d", res.Code)
+	}
+	if res.Header().Get("Content-Length") != "11" {
+		t.Errorf("expected Content-Length 11, got %q", res.Header().Get("Content-Length"))
+	}
+	if res.Body.Len() != 0 {
+		t.Errorf("expected no body, got %q", res.Body.String())
+	}
+}
+
+func TestRouterAutoHeadExplicitHandlerTakesPrecedence(t *testing.T) {
+	calledGetHandler := false
+
+	router := navaros.NewRouter()
+	router.Get("/test", func(ctx *navaros.Context) {
+		calledGetHandler = true
+		ctx.Body = "from get"
+	})
+	router.Head("/test", func(ctx *navaros.Context) {
+		ctx.Status = 204
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("HEAD", "/test", nil))
+
+	if calledGetHandler {
+		t.Error("expected GET handler not to be called")
+	}
+	if res.Code != 204 {
+		t.Errorf("expected 204, got %d", res.Code)
+	}
+}
+
+func TestRouterSetAutoHead(t *testing.T) {
+	router := navaros.NewRouter()
+	router.SetAutoHead(false)
+	router.Get("/test", func(ctx *navaros.Context) {
+		ctx.Body = "Hello World"
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("HEAD", "/test", nil))
+
+	if res.Code != 405 {
+		t.Errorf("expected 405 with implicit HEAD disabled, got %d", res.Code)
+	}
+}
+
+func TestRouterUseWithPathMountsSubRouter(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.Get("/users/:id", func(ctx *navaros.Context) {
+		ctx.Status = 200
+		ctx.Body = ctx.Params().Get("id") + " " + ctx.Path()
+	})
+
+	router := navaros.NewRouter()
+	router.Use("/api", subRouter)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/api/users/1", nil))
+
+	if res.Code != 200 {
+		t.Errorf("expected 200, got %d", res.Code)
+	}
+	if res.Body.String() != "1 /api/users/1" {
+		t.Errorf("expected '1 /api/users/1', got %q", res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/users/1", nil))
+
+	if res.Code != 404 {
+		t.Errorf("expected 404 outside the mount path, got %d", res.Code)
+	}
+}
+
+func TestRouterUseWithoutPathMatchesFullPath(t *testing.T) {
+	subRouter := navaros.NewRouter()
+	subRouter.Get("/api/users/:id", func(ctx *navaros.Context) {
+		ctx.Body = ctx.Params().Get("id")
+	})
+
+	router := navaros.NewRouter()
+	router.Use(subRouter)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/api/users/1", nil))
+	if res.Body.String() != "1" {
+		t.Errorf("expected routes of a router mounted without a path to match the full path, got %d %q", res.Code, res.Body.String())
 	}
 }
 
@@ -1052,3 +2034,46 @@ func TestRouter_Wrap_PanicsOnTransformer(t *testing.T) {
 	router := navaros.NewRouter()
 	router.Wrap(&wrapTestTransformer{})
 }
+
+func TestRouterMatchesInRegistrationOrderAcrossPrefixes(t *testing.T) {
+	var order []string
+
+	router := navaros.NewRouter()
+	router.Get("/a/b/c", func(ctx *navaros.Context) {
+		order = append(order, "static")
+		ctx.Next()
+	})
+	router.Use(func(ctx *navaros.Context) {
+		order = append(order, "middleware")
+		ctx.Next()
+	})
+	router.Get("/a/:b/c", func(ctx *navaros.Context) {
+		order = append(order, "dynamic")
+		ctx.Next()
+	})
+	router.Use("/a", func(ctx *navaros.Context) {
+		order = append(order, "mounted")
+		ctx.Next()
+	})
+	router.Get("/a/b/c", func(ctx *navaros.Context) {
+		order = append(order, "static-again")
+		ctx.Status = 200
+	})
+	router.Get("/a/b/d", func(ctx *navaros.Context) {
+		order = append(order, "wrong")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/a/b/c/", nil))
+
+	expected := []string{"static", "middleware", "dynamic", "mounted", "static-again"}
+	if len(order) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, order)
+			break
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package navaros
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// timeoutWriteGrace is how long past a timeout's deadline the connection's
+// write deadline is set, so the timeout response can still be written.
+const timeoutWriteGrace = 5 * time.Second
+
+// TimeoutOptions configures the response sent when a request times out.
+type TimeoutOptions struct {
+	// Status is the status of the timeout response. It defaults to 503
+	// Service Unavailable. 504 Gateway Timeout suits handlers which wait on
+	// upstream services.
+	Status int
+	// Body is the body of the timeout response. It defaults to the status
+	// text of Status.
+	Body string
+	// ContentType is the content type of Body. It defaults to
+	// text/plain; charset=utf-8.
+	ContentType string
+}
+
+// TimeoutOption is a RouteOption that limits how long a route's handlers may
+// take to respond.
+type TimeoutOption struct {
+	timeout time.Duration
+	options *TimeoutOptions
+}
+
+func (TimeoutOption) isRouteOption() {}
+
+// WithTimeout creates a RouteOption that limits how long a route, or a
+// mounted router, may take to respond, as the Timeout middleware does. The
+// timeout covers the route's handlers and any handlers they call
+// ctx.Next() into. Options may be nil to use the defaults.
+func WithTimeout(timeout time.Duration, options *TimeoutOptions) TimeoutOption {
+	checkTimeout(timeout)
+	return TimeoutOption{timeout: timeout, options: options}
+}
+
+// Timeout creates middleware which limits how long the handlers after it may
+// take to respond. Options may be nil to use the defaults.
+//
+// When the timeout passes the context's Done channel is closed, and if no
+// response has been started, the timeout response is sent to the client
+// right away. Anything written by handlers afterwards is discarded. Handlers
+// are not interrupted, so long running handlers should watch ctx.Done(), or
+// pass the context to anything that takes a go context.Context, and return
+// once it's done. The request is not complete until they do.
+//
+// The connection's read deadline is set to the timeout, so reads of the
+// request body do not block past it, as is its write deadline, with a short
+// grace period for the timeout response. Both are cleared once the handlers
+// return. Timeout should be used before any middleware which replaces the
+// response writer with SetResponseBodyWriter, as writes to such writers
+// cannot be guarded.
+func Timeout(timeout time.Duration, options *TimeoutOptions) HandlerFunc {
+	checkTimeout(timeout)
+	if options == nil {
+		options = &TimeoutOptions{}
+	}
+	status := options.Status
+	if status == 0 {
+		status = http.StatusServiceUnavailable
+	}
+	body := options.Body
+	if body == "" {
+		body = http.StatusText(status)
+	}
+	contentType := options.ContentType
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+	response := &timeoutResponse{status: status, contentType: contentType, body: body}
+
+	return func(ctx *Context) {
+		deadline := time.Now().Add(timeout)
+		if current, ok := ctx.Deadline(); ok && current.Before(deadline) {
+			deadline = current
+		}
+		ctx.nextWithTimeout(deadline, response)
+	}
+}
+
+// timeoutResponse is the response sent when a request times out.
+type timeoutResponse struct {
+	status      int
+	contentType string
+	body        string
+}
+
+// checkTimeout panics if a timeout is not positive.
+func checkTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		panic("timeout must be greater than zero")
+	}
+}
+
+// nextWithTimeout calls Next with the context's deadline set, writing the
+// timeout response if the deadline passes before the handlers return.
+func (c *Context) nextWithTimeout(deadline time.Time, response *timeoutResponse) {
+	previousDeadline := c.deadline
+	c.setDeadline(&deadline)
+
+	if c.responseWriter == nil {
+		c.Next()
+		c.setDeadline(previousDeadline)
+		return
+	}
+
+	controller := http.NewResponseController(c.responseWriter)
+	hasReadDeadline := controller.SetReadDeadline(deadline) == nil
+	hasWriteDeadline := controller.SetWriteDeadline(deadline.Add(timeoutWriteGrace)) == nil
+
+	writer := c.guardResponseWriter(deadline, response)
+	timer := time.AfterFunc(time.Until(deadline), writer.timeout)
+
+	c.Next()
+
+	timer.Stop()
+	timedOut := writer.stop()
+
+	readDeadline, writeDeadline := time.Time{}, time.Time{}
+	if previousDeadline != nil {
+		readDeadline, writeDeadline = *previousDeadline, previousDeadline.Add(timeoutWriteGrace)
+	}
+	if hasReadDeadline {
+		_ = controller.SetReadDeadline(readDeadline)
+	}
+	if hasWriteDeadline {
+		_ = controller.SetWriteDeadline(writeDeadline)
+	}
+
+	if timedOut {
+		// The timeout response has been sent, so the context's response is
+		// never written.
+		c.hasWrittenResponse = true
+		return
+	}
+	c.setDeadline(previousDeadline)
+}
+
+// guardResponseWriter places a timeoutWriter with the given deadline and
+// response in front of the context's response writer, including any
+// ContextResponseWriter already writing to it, and returns it.
+func (c *Context) guardResponseWriter(deadline time.Time, response *timeoutResponse) *timeoutWriter {
+	writer := &timeoutWriter{
+		responseWriter: c.responseWriter,
+		header:         c.responseWriter.Header().Clone(),
+		deadline:       deadline,
+		response:       response,
+	}
+	if contextResponseWriter, ok := c.bodyWriter.(*ContextResponseWriter); ok && contextResponseWriter.bodyWriter == c.responseWriter {
+		contextResponseWriter.bodyWriter = writer
+	}
+	c.responseWriter = writer
+	return writer
+}
+
+// timeoutWriter guards a response writer written to by handlers with a
+// timeout. Once the deadline passes, it writes the timeout response, unless
+// a response has already been started, and discards any later writes. Writes
+// made after the deadline are discarded even if its timer has yet to fire,
+// so a response is never started once the deadline has passed. Headers
+// set by handlers are kept apart from those of the underlying writer until
+// the response is started, so that the timeout response never includes
+// them.
+type timeoutWriter struct {
+	mu             sync.Mutex
+	responseWriter http.ResponseWriter
+	header         http.Header
+	deadline       time.Time
+	response       *timeoutResponse
+	wroteHeader    bool
+	timedOut       bool
+	stopped        bool
+}
+
+var _ http.ResponseWriter = &timeoutWriter{}
+var _ http.Flusher = &timeoutWriter{}
+var _ http.Hijacker = &timeoutWriter{}
+
+func (t *timeoutWriter) Header() http.Header {
+	return t.header
+}
+
+func (t *timeoutWriter) WriteHeader(status int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.hasTimedOut() || t.wroteHeader {
+		return
+	}
+	t.writeHeader(status)
+}
+
+func (t *timeoutWriter) Write(bytes []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.hasTimedOut() {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !t.wroteHeader {
+		t.writeHeader(http.StatusOK)
+	}
+	return t.responseWriter.Write(bytes)
+}
+
+func (t *timeoutWriter) Flush() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.hasTimedOut() {
+		return
+	}
+	if !t.wroteHeader {
+		t.writeHeader(http.StatusOK)
+	}
+	if flusher, ok := t.responseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (t *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.hasTimedOut() {
+		return nil, nil, http.ErrHandlerTimeout
+	}
+	hijacker, ok := t.responseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	// The connection belongs to the handler once hijacked, so the timeout
+	// response must never be written to it.
+	t.wroteHeader = true
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the underlying response writer, so http.ResponseController
+// can reach it.
+func (t *timeoutWriter) Unwrap() http.ResponseWriter {
+	return t.responseWriter
+}
+
+// writeHeader copies the handlers' headers to the underlying writer, and
+// writes the status. It must be called with the writer's lock held.
+func (t *timeoutWriter) writeHeader(status int) {
+	header := t.responseWriter.Header()
+	clear(header)
+	for key, values := range t.header {
+		header[key] = values
+	}
+	t.responseWriter.WriteHeader(status)
+	t.wroteHeader = true
+}
+
+// timeout is called by the deadline's timer.
+func (t *timeoutWriter) timeout() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hasTimedOut()
+}
+
+// stop is called once the handlers return. It reports whether they timed
+// out, such as when they return just after the deadline because it caused
+// their reads to fail. If they didn't, the timeout response will never be
+// written, and the writer passes writes through from then on.
+func (t *timeoutWriter) stop() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	timedOut := t.hasTimedOut()
+	t.stopped = true
+	return timedOut
+}
+
+// hasTimedOut reports whether the handlers have timed out. The first time
+// it's called after the deadline, the timeout response is written, unless
+// the handlers have returned or already started a response. It must be
+// called with the writer's lock held.
+func (t *timeoutWriter) hasTimedOut() bool {
+	if t.timedOut || t.stopped || time.Now().Before(t.deadline) {
+		return t.timedOut
+	}
+	t.timedOut = true
+	if t.wroteHeader {
+		return true
+	}
+
+	header := t.responseWriter.Header()
+	header.Set("Content-Type", t.response.contentType)
+	header.Set("Content-Length", strconv.Itoa(len(t.response.body)))
+	t.responseWriter.WriteHeader(t.response.status)
+	_, _ = t.responseWriter.Write([]byte(t.response.body))
+	if flusher, ok := t.responseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package navaros_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RobertWHurst/navaros"
+)
+
+func TestTimeout(t *testing.T) {
+	lateWrite := make(chan error, 1)
+	router := navaros.NewRouter()
+	router.Use(navaros.Timeout(20*time.Millisecond, nil))
+	router.Get("/slow", func(ctx *navaros.Context) {
+		ctx.Headers.Set("X-Slow", "yes")
+		<-ctx.Done()
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			t.Errorf("expected the deadline to be exceeded, got %v", ctx.Err())
+		}
+		_, err := ctx.ResponseWriter().Write([]byte("too late"))
+		lateWrite <- err
+		ctx.Body = "too late"
+	})
+	router.Get("/fast", func(ctx *navaros.Context) {
+		ctx.Body = "fast"
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/slow", nil))
+	if res.Code != http.StatusServiceUnavailable || res.Body.String() != "Service Unavailable" {
+		t.Errorf("expected 503 Service Unavailable, got %d %s", res.Code, res.Body.String())
+	}
+	if res.Header().Get("X-Slow") != "" {
+		t.Error("expected the handler's headers to be discarded")
+	}
+	if err := <-lateWrite; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Errorf("expected late writes to fail with ErrHandlerTimeout, got %v", err)
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/fast", nil))
+	if res.Code != http.StatusOK || res.Body.String() != "fast" {
+		t.Errorf("expected 200 fast, got %d %s", res.Code, res.Body.String())
+	}
+}
+
+func TestTimeoutOptions(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Use(navaros.Timeout(20*time.Millisecond, &navaros.TimeoutOptions{
+		Status:      http.StatusGatewayTimeout,
+		Body:        `{"error":"timeout"}`,
+		ContentType: "application/json",
+	}))
+	router.Get("/slow", func(ctx *navaros.Context) {
+		<-ctx.Done()
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/slow", nil))
+	if res.Code != http.StatusGatewayTimeout || res.Body.String() != `{"error":"timeout"}` {
+		t.Errorf("expected the configured response, got %d %s", res.Code, res.Body.String())
+	}
+	if res.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected the configured content type, got %s", res.Header().Get("Content-Type"))
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	var deadlineAfterRoute bool
+	router := navaros.NewRouter()
+	router.Use(func(ctx *navaros.Context) {
+		ctx.Next()
+		_, deadlineAfterRoute = ctx.Deadline()
+	})
+	router.Get("/slow", func(ctx *navaros.Context) {
+		<-ctx.Done()
+	}, navaros.WithTimeout(20*time.Millisecond, nil))
+	router.Get("/fast", func(ctx *navaros.Context) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("expected the route to have a deadline")
+		}
+		ctx.Body = "fast"
+	}, navaros.WithTimeout(time.Second, nil))
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/slow", nil))
+	if res.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/fast", nil))
+	if res.Code != http.StatusOK || res.Body.String() != "fast" {
+		t.Errorf("expected 200 fast, got %d %s", res.Code, res.Body.String())
+	}
+	if deadlineAfterRoute {
+		t.Error("expected the route's deadline to be removed once it returned")
+	}
+}
+
+func TestTimeoutWithServer(t *testing.T) {
+	router := navaros.NewRouter()
+	router.Post("/upload", func(ctx *navaros.Context) {
+		_, err := io.ReadAll(ctx.Request().Body)
+		if err == nil {
+			t.Error("expected reading the body to fail once the deadline passed")
+		}
+	}, navaros.WithTimeout(50*time.Millisecond, nil))
+	router.Get("/fast", func(ctx *navaros.Context) {
+		ctx.Body = "fast"
+	}, navaros.WithTimeout(50*time.Millisecond, nil))
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	body, writer := io.Pipe()
+	defer writer.Close()
+	go writer.Write([]byte("partial"))
+	res, err := http.Post(server.URL+"/upload", "text/plain", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responseBody, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusServiceUnavailable || strings.TrimSpace(string(responseBody)) != "Service Unavailable" {
+		t.Errorf("expected 503 Service Unavailable, got %d %s", res.StatusCode, responseBody)
+	}
+
+	// The connection's deadlines are cleared, so it can be reused once the
+	// timeout has long passed.
+	client := &http.Client{}
+	for i := 0; i < 2; i++ {
+		res, err = client.Get(server.URL + "/fast")
+		if err != nil {
+			t.Fatal(err)
+		}
+		responseBody, _ = io.ReadAll(res.Body)
+		res.Body.Close()
+		if string(responseBody) != "fast" {
+			t.Errorf("expected fast, got %d %s", res.StatusCode, responseBody)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}